@@ -0,0 +1,101 @@
+package teepr
+
+import (
+	"reflect"
+)
+
+// Clone returns a deep copy of v. Maps, slices, pointers and interfaces are copied
+// recursively, a value referenced several times, including through a cycle, is copied once.
+// Unexported fields and the fields of time.Time and the sql.Null* types are copied by value
+func Clone(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	c := &cloner{visited: make(map[visit]reflect.Value)}
+	return c.clone(reflect.ValueOf(v)).Interface()
+}
+
+// visit identifies a reference already copied
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+type cloner struct {
+	visited map[visit]reflect.Value
+}
+
+func (c *cloner) clone(val reflect.Value) reflect.Value {
+	typ := val.Type()
+
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			return reflect.Zero(typ)
+		}
+		key := visit{val.Pointer(), typ, 0}
+		if out, found := c.visited[key]; found {
+			return out
+		}
+		out := reflect.New(typ.Elem())
+		c.visited[key] = out
+		out.Elem().Set(c.clone(val.Elem()))
+		return out
+	case reflect.Interface:
+		out := reflect.New(typ).Elem()
+		if !val.IsNil() {
+			out.Set(c.clone(val.Elem()))
+		}
+		return out
+	case reflect.Map:
+		if val.IsNil() {
+			return reflect.Zero(typ)
+		}
+		key := visit{val.Pointer(), typ, 0}
+		if out, found := c.visited[key]; found {
+			return out
+		}
+		out := reflect.MakeMapWithSize(typ, val.Len())
+		c.visited[key] = out
+		for _, k := range val.MapKeys() {
+			out.SetMapIndex(c.clone(k), c.clone(val.MapIndex(k)))
+		}
+		return out
+	case reflect.Slice:
+		if val.IsNil() {
+			return reflect.Zero(typ)
+		}
+		key := visit{val.Pointer(), typ, val.Len()}
+		if out, found := c.visited[key]; found {
+			return out
+		}
+		out := reflect.MakeSlice(typ, val.Len(), val.Cap())
+		c.visited[key] = out
+		for i := 0; i < val.Len(); i++ {
+			out.Index(i).Set(c.clone(val.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(typ).Elem()
+		for i := 0; i < val.Len(); i++ {
+			out.Index(i).Set(c.clone(val.Index(i)))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(typ).Elem()
+		out.Set(val)
+		if isLeafStruct(typ) {
+			return out
+		}
+		for i := 0; i < val.NumField(); i++ {
+			if typ.Field(i).PkgPath == "" {
+				out.Field(i).Set(c.clone(val.Field(i)))
+			}
+		}
+		return out
+	}
+
+	return val
+}
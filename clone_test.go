@@ -0,0 +1,79 @@
+package teepr
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type MenuNode struct {
+	Name     string
+	Parent   *MenuNode
+	Children []*MenuNode
+}
+
+type AggregateSnapshot struct {
+	Id         [4]byte
+	Name       sql.NullString
+	CreatedAt  time.Time
+	Attributes map[string]interface{}
+	Items      []OrderItem
+	Payload    interface{}
+	Owner      *UserExample
+}
+
+func TestClone(t *testing.T) {
+	t.Log("Testing Clone of a struct with maps, slices, pointers and interfaces")
+	{
+		snapshot := AggregateSnapshot{
+			Id:        [4]byte{1, 2, 3, 4},
+			Name:      sql.NullString{String: "snapshot", Valid: true},
+			CreatedAt: time.Now(),
+			Attributes: map[string]interface{}{
+				"tags": []interface{}{"a", "b"},
+			},
+			Items:   []OrderItem{{Id: "itm123", ItemName: "XL 2 Giga", Price: 150000}},
+			Payload: map[string]interface{}{"status": "OrderCreated"},
+			Owner:   &UserExample{FirstName: "firstex"},
+		}
+
+		clone, ok := Clone(snapshot).(AggregateSnapshot)
+		if !ok {
+			t.Fatalf("%s expected clone of type AggregateSnapshot", failed)
+		}
+		if !reflect.DeepEqual(snapshot, clone) {
+			t.Fatalf("%s expected clone = %+v, got %+v", failed, snapshot, clone)
+		}
+
+		clone.Attributes["tags"].([]interface{})[0] = "changed"
+		clone.Items[0].Price = 1
+		clone.Payload.(map[string]interface{})["status"] = "changed"
+		clone.Owner.FirstName = "changed"
+
+		if snapshot.Attributes["tags"].([]interface{})[0] != "a" || snapshot.Items[0].Price != 150000 ||
+			snapshot.Payload.(map[string]interface{})["status"] != "OrderCreated" || snapshot.Owner.FirstName != "firstex" {
+			t.Fatalf("%s expected the original is not shared with the clone, got %+v", failed, snapshot)
+		}
+		t.Logf("%s Result: %+v", success, clone)
+	}
+
+	t.Log("Testing Clone of a self-referencing graph")
+	{
+		root := &MenuNode{Name: "root"}
+		child := &MenuNode{Name: "child", Parent: root}
+		root.Children = []*MenuNode{child, child}
+
+		clone := Clone(root).(*MenuNode)
+		if clone == root || clone.Children[0] == child {
+			t.Fatalf("%s expected new nodes in the clone", failed)
+		}
+		if clone.Children[0].Parent != clone {
+			t.Fatalf("%s expected the cycle is kept in the clone", failed)
+		}
+		if clone.Children[0] != clone.Children[1] {
+			t.Fatalf("%s expected a shared node is cloned once", failed)
+		}
+		t.Logf("%s Result: %+v", success, clone)
+	}
+}
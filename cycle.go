@@ -0,0 +1,54 @@
+package teepr
+
+import (
+	"reflect"
+)
+
+// WithSharedReferences makes two references to one source value map to one output value,
+// so pointer graphs with cycles, like parent and child pointers, keep their shape
+func WithSharedReferences() Option {
+	return func(m *Mapper) {
+		m.shareRefs = true
+	}
+}
+
+// sharedKey identifies the output value created for a source pointer
+type sharedKey struct {
+	visit
+	out reflect.Type
+}
+
+// enter records the source pointer in the traversal path, it returns false when the
+// pointer is already there, meaning the source has a cycle
+func (it *iteration) enter(in, out reflect.Value) bool {
+	key := visit{in.Pointer(), in.Type(), 0}
+	if it.stack[key] {
+		return false
+	}
+
+	if it.stack == nil {
+		it.stack = make(map[visit]bool)
+	}
+	it.stack[key] = true
+
+	if it.shareRefs && out.Kind() == reflect.Ptr {
+		if it.shared == nil {
+			it.shared = make(map[sharedKey]reflect.Value)
+		}
+		it.shared[sharedKey{key, out.Type()}] = out
+	}
+	return true
+}
+
+func (it *iteration) leave(in reflect.Value) {
+	delete(it.stack, visit{in.Pointer(), in.Type(), 0})
+}
+
+// sharedRef gives the output value already created for a source pointer
+func (it *iteration) sharedRef(in reflect.Value, out reflect.Type) (reflect.Value, bool) {
+	if !it.shareRefs || in.Kind() != reflect.Ptr || in.IsNil() {
+		return reflect.Value{}, false
+	}
+	ref, found := it.shared[sharedKey{visit{in.Pointer(), in.Type(), 0}, out}]
+	return ref, found
+}
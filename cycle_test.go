@@ -0,0 +1,50 @@
+package teepr
+
+import (
+	"testing"
+)
+
+type MenuNodeView struct {
+	Name     string
+	Parent   *MenuNodeView
+	Children []*MenuNodeView
+}
+
+func TestCycle(t *testing.T) {
+	root := &MenuNode{Name: "root"}
+	child := &MenuNode{Name: "child", Parent: root}
+	root.Children = []*MenuNode{child, child}
+
+	t.Log("Testing a self-referencing graph is reported as a cycle")
+	{
+		output := MenuNodeView{}
+		err := Teepr(root, &output)
+		errs, ok := err.(Errors)
+		if !ok {
+			t.Fatalf("%s expected error of type Errors, got %v", failed, err)
+		}
+		if !hasFieldError(errs, "Children.0.Parent", ErrCycle) {
+			t.Fatalf("%s expected a cycle at Children.0.Parent, got %v", failed, errs)
+		}
+		t.Logf("%s Result: %v", success, err)
+	}
+
+	t.Log("Testing a self-referencing graph with shared references")
+	{
+		output := MenuNodeView{}
+		err := NewMapper(WithSharedReferences()).Teepr(root, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(output.Children) != 2 || output.Children[0].Name != "child" {
+			t.Fatalf("%s expected 2 children, got %+v", failed, output.Children)
+		}
+		if output.Children[0].Parent != &output {
+			t.Fatalf("%s expected the child parent is the output root", failed)
+		}
+		if output.Children[0] != output.Children[1] {
+			t.Fatalf("%s expected one output child for the shared source child", failed)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+}
@@ -0,0 +1,100 @@
+package teepr
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// WithDefaultOnZero applies the defaults of `teepr:",default=..."` also to the fields
+// given a zero value by the input, not only to the fields the input has no value for
+func WithDefaultOnZero() Option {
+	return func(m *Mapper) {
+		m.defaultOnZero = true
+	}
+}
+
+// applyDefaults sets the default of every field of the struct oval tagged `teepr:",default=..."`
+// left zero by the mapping, the fields set are added to matched
+func (it *iteration) applyDefaults(oval reflect.Value, path string, matched map[string]bool) {
+	for _, f := range it.profileFields(oval.Type()) {
+		def, ok := tagValue(f.StructField, "default")
+		fpath := joinPath(path, f.Name)
+		if !ok || matched[f.Name] && !it.defaultOnZero || !it.inMask(fpath) {
+			continue
+		}
+		fval, ok := fieldByIndex(oval, f.index, true)
+		if !ok || !fval.IsZero() {
+			continue
+		}
+
+		if err := setDefault(fval, def); err != nil {
+			it.addError(fpath, err)
+			continue
+		}
+		matched[f.Name] = true
+	}
+}
+
+// setDefault parses the default value of a field into val, times are parsed
+// as RFC 3339 or with DefaultDateLayout
+func setDefault(val reflect.Value, def string) error {
+	if val.Kind() == reflect.Ptr {
+		elem := reflect.New(val.Type().Elem())
+		if err := setDefault(elem.Elem(), def); err != nil {
+			return err
+		}
+		val.Set(elem)
+		return nil
+	}
+
+	switch {
+	case val.Type() == timeType:
+		t, err := time.Parse(time.RFC3339, def)
+		if err != nil {
+			t, err = time.Parse(DefaultDateLayout, def)
+		}
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(t))
+	case val.Type() == durationType:
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return err
+		}
+		val.SetInt(int64(d))
+	case val.Kind() == reflect.String:
+		val.SetString(def)
+	case val.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(def)
+		if err != nil {
+			return err
+		}
+		val.SetBool(b)
+	case val.Kind() >= reflect.Int && val.Kind() <= reflect.Int64:
+		i, err := strconv.ParseInt(def, 10, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetInt(i)
+	case val.Kind() >= reflect.Uint && val.Kind() <= reflect.Uint64:
+		u, err := strconv.ParseUint(def, 10, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetUint(u)
+	case val.Kind() == reflect.Float32 || val.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(def, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetFloat(f)
+	default:
+		return fmt.Errorf("no default for type %s", val.Type())
+	}
+	return nil
+}
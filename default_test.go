@@ -0,0 +1,63 @@
+package teepr
+
+import (
+	"testing"
+	"time"
+)
+
+type ShipmentEntity struct {
+	Id        string
+	Status    string        `teepr:",default=PENDING"`
+	Priority  int           `teepr:",default=3"`
+	Insured   bool          `teepr:",default=true"`
+	Fee       *float64      `teepr:",default=1.5"`
+	Timeout   time.Duration `teepr:",default=90s"`
+	PickupAt  time.Time     `teepr:",default=2020-01-02T15:04:05Z"`
+	Reference string
+}
+
+func TestDefaults(t *testing.T) {
+	t.Log("Testing defaults of the fields missing from a map input")
+	{
+		input := map[string]interface{}{"Id": "shp123", "Priority": 0}
+		var output ShipmentEntity
+		if err := Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Status != "PENDING" || !output.Insured || output.Fee == nil || *output.Fee != 1.5 || output.Timeout != 90*time.Second {
+			t.Fatalf("%s expected defaults set, got %+v", failed, output)
+		}
+		if !output.PickupAt.Equal(time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)) {
+			t.Fatalf("%s expected PickupAt default, got %s", failed, output.PickupAt)
+		}
+		if output.Priority != 0 {
+			t.Fatalf("%s expected Priority given by the input kept, got %d", failed, output.Priority)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing defaults of the fields given a zero value with WithDefaultOnZero")
+	{
+		input := ShipmentEntity{Id: "shp123", Status: ""}
+		var output ShipmentEntity
+		if err := NewMapper(WithDefaultOnZero(), WithStrictFields()).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Status != "PENDING" || output.Priority != 3 {
+			t.Fatalf("%s expected defaults set, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing defaults leave existing output values alone")
+	{
+		output := ShipmentEntity{Status: "SHIPPED"}
+		if err := Teepr(map[string]interface{}{"Id": "shp123"}, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Status != "SHIPPED" {
+			t.Fatalf("%s expected Status = SHIPPED, got %s", failed, output.Status)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+}
@@ -0,0 +1,147 @@
+package teepr
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ChangeKind tells how a value differs between the two sides of Diff
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Modified ChangeKind = "modified"
+)
+
+// Change is a difference found by Diff at the dotted path of a leaf value. A leaf replaced
+// by nested values, like a nil pointer set to a struct, is a single Modified change whose
+// nested side holds the values keyed by their path relative to the change
+type Change struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+	Kind ChangeKind
+}
+
+// Diff lists the changes from a to b, sorted by path
+func Diff(a, b interface{}) ([]Change, error) {
+	return NewMapper().Diff(a, b)
+}
+
+// Diff lists the changes from a to b, sorted by path. A value referencing itself,
+// like a child pointing to its parent, fails with ErrCycle. When b is not of the type of a,
+// it is first mapped into a value of that type with the rules of the Mapper
+func (m *Mapper) Diff(a, b interface{}) ([]Change, error) {
+	if a != nil && b != nil {
+		atyp := reflect.Indirect(reflect.ValueOf(a)).Type()
+		if btyp := reflect.Indirect(reflect.ValueOf(b)).Type(); btyp != atyp {
+			out := reflect.New(atyp)
+			if err := m.Teepr(b, out.Interface()); err != nil {
+				return nil, err
+			}
+			b = out.Interface()
+		}
+	}
+
+	fa, fb := newFlattener(), newFlattener()
+	if a != nil {
+		fa.flatten(reflect.ValueOf(a), "")
+	}
+	if b != nil {
+		fb.flatten(reflect.ValueOf(b), "")
+	}
+	if err := fa.cycleErrors(); err != nil {
+		return nil, err
+	}
+	if err := fb.cycleErrors(); err != nil {
+		return nil, err
+	}
+
+	olds, news := fa.result, fb.result
+	var changes []Change
+	for path, old := range olds {
+		v, found := news[path]
+		if !found {
+			if hasLeafAncestor(news, path) {
+				continue
+			}
+			if sub := subtree(news, path); len(sub) > 0 {
+				changes = append(changes, Change{Path: path, Old: old, New: sub, Kind: Modified})
+			} else {
+				changes = append(changes, Change{Path: path, Old: old, Kind: Removed})
+			}
+		} else if !valuesEqual(old, v) {
+			changes = append(changes, Change{Path: path, Old: old, New: v, Kind: Modified})
+		}
+	}
+	for path, v := range news {
+		if _, found := olds[path]; found || hasLeafAncestor(olds, path) {
+			continue
+		}
+		if sub := subtree(olds, path); len(sub) > 0 {
+			changes = append(changes, Change{Path: path, Old: sub, New: v, Kind: Modified})
+		} else {
+			changes = append(changes, Change{Path: path, New: v, Kind: Added})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// subtree gives the values of flat below path keyed by their path relative to it,
+// e.g. the fields of a pointer that is nil on the other side of Diff
+func subtree(flat map[string]interface{}, path string) map[string]interface{} {
+	prefix := path + FlattenSeparator
+	sub := make(map[string]interface{})
+	for p, v := range flat {
+		if strings.HasPrefix(p, prefix) {
+			sub[strings.TrimPrefix(p, prefix)] = v
+		}
+	}
+	return sub
+}
+
+// hasLeafAncestor reports whether a value above path is a leaf of flat,
+// the change is then reported once at the path of that leaf
+func hasLeafAncestor(flat map[string]interface{}, path string) bool {
+	for i := strings.Index(path, FlattenSeparator); i >= 0; {
+		if _, found := flat[path[:i]]; found {
+			return true
+		}
+		next := strings.Index(path[i+1:], FlattenSeparator)
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+// valuesEqual compares two leaf values of Flatten, time values are compared with time.Equal,
+// numbers of different types by their value and empty collections are equal whether they are nil or not
+func valuesEqual(x, y interface{}) bool {
+	if tx, ok := x.(time.Time); ok {
+		ty, ok := y.(time.Time)
+		return ok && tx.Equal(ty)
+	}
+
+	vx, vy := reflect.ValueOf(x), reflect.ValueOf(y)
+	if vx.IsValid() && vy.IsValid() && vx.Type() != vy.Type() && isNumberKind(vx.Kind()) && isNumberKind(vy.Kind()) {
+		return vx.Convert(reflect.TypeOf(float64(0))).Float() == vy.Convert(reflect.TypeOf(float64(0))).Float()
+	}
+	if vx.IsValid() && vy.IsValid() && vx.Type() == vy.Type() {
+		switch vx.Kind() {
+		case reflect.Slice, reflect.Map:
+			if vx.Len() == 0 && vy.Len() == 0 {
+				return true
+			}
+		}
+	}
+	return reflect.DeepEqual(x, y)
+}
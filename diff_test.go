@@ -0,0 +1,121 @@
+package teepr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	now := time.Now()
+
+	t.Log("Testing Diff between two values of the same type")
+	{
+		before := OrderEx{
+			Id:      "o123",
+			Created: now,
+			Status:  "OrderCreated",
+			Items: []OrderItem{
+				{Id: "itm123", ItemName: "XL 2 Giga", Price: 150000},
+			},
+		}
+		after := OrderEx{
+			Id:      "o123",
+			Created: now.Round(0),
+			Status:  "OrderPaid",
+			Items: []OrderItem{
+				{Id: "itm123", ItemName: "XL 2 Giga", Price: 160000},
+				{Id: "itm124", ItemName: "XL 5 Giga", Price: 300000},
+			},
+		}
+
+		changes, err := Diff(before, after)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		expected := []Change{
+			{Path: "Items.0.Price", Old: 150000.0, New: 160000.0, Kind: Modified},
+			{Path: "Items.1.Id", New: "itm124", Kind: Added},
+			{Path: "Items.1.ItemName", New: "XL 5 Giga", Kind: Added},
+			{Path: "Items.1.Price", New: 300000.0, Kind: Added},
+			{Path: "Status", Old: "OrderCreated", New: "OrderPaid", Kind: Modified},
+		}
+		if len(changes) != len(expected) {
+			t.Fatalf("%s expected %d changes, got %+v", failed, len(expected), changes)
+		}
+		for i, c := range changes {
+			if c != expected[i] {
+				t.Fatalf("%s expected change %+v, got %+v", failed, expected[i], c)
+			}
+		}
+		t.Logf("%s Result: %+v", success, changes)
+
+		changes, err = Diff(after, before)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(changes) != 5 || changes[1].Kind != Removed || changes[1].Old != "itm124" {
+			t.Fatalf("%s expected Items.1.Id removed, got %+v", failed, changes)
+		}
+	}
+
+	t.Log("Testing Diff between two values of different types")
+	{
+		item := OrderItem{Id: "itm123", ItemName: "XL 2 Giga", Price: 150000}
+		other := Item{Id: "itm123", ItemName: "XL 3 Giga", Price: 150000}
+
+		changes, err := Diff(item, other)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(changes) != 1 || changes[0].Path != "ItemName" || changes[0].New != "XL 3 Giga" {
+			t.Fatalf("%s expected ItemName modified, got %+v", failed, changes)
+		}
+		t.Logf("%s Result: %+v", success, changes)
+	}
+
+	t.Log("Testing Diff of a nil pointer replaced by a value and the reverse")
+	{
+		type OwnedOrder struct {
+			Id    string
+			Owner *UserExample
+		}
+		before := OwnedOrder{Id: "o123"}
+		after := OwnedOrder{Id: "o123", Owner: &UserExample{FirstName: "firstex"}}
+
+		changes, err := Diff(before, after)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(changes) != 1 || changes[0].Path != "Owner" || changes[0].Kind != Modified || changes[0].Old != nil {
+			t.Fatalf("%s expected one modified change at Owner, got %+v", failed, changes)
+		}
+		if sub, ok := changes[0].New.(map[string]interface{}); !ok || sub["FirstName"] != "firstex" {
+			t.Fatalf("%s expected the new Owner fields, got %+v", failed, changes[0].New)
+		}
+
+		changes, err = Diff(after, before)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(changes) != 1 || changes[0].Path != "Owner" || changes[0].Kind != Modified || changes[0].New != nil {
+			t.Fatalf("%s expected one modified change at Owner, got %+v", failed, changes)
+		}
+		t.Logf("%s Result: %+v", success, changes)
+	}
+
+	t.Log("Testing Diff self-referencing values")
+	{
+		before := &MenuNode{Name: "root"}
+		before.Children = []*MenuNode{{Name: "child", Parent: before}}
+		after := &MenuNode{Name: "menu"}
+		after.Children = []*MenuNode{{Name: "child", Parent: after}}
+
+		_, err := Diff(before, after)
+		errs, ok := err.(Errors)
+		if !ok || !hasFieldError(errs, "Children.0.Parent", ErrCycle) {
+			t.Fatalf("%s expected a cycle at Children.0.Parent, got %v", failed, err)
+		}
+		t.Logf("%s Result: %s", success, err.Error())
+	}
+}
@@ -0,0 +1,68 @@
+package teepr
+
+import (
+	"reflect"
+)
+
+// Unmappable marks a value Equivalent could not map into the type of the other side
+const Unmappable ChangeKind = "unmappable"
+
+// Mismatch is a value lost when one side of Equivalent is mapped into the type of the other.
+// Reverse is false when a is mapped into the type of b, the path is then a path of b
+type Mismatch struct {
+	Change
+	Reverse bool
+}
+
+// Equivalent reports whether a and b, possibly of different types, map to each other without loss
+func Equivalent(a, b interface{}) (bool, []Mismatch) {
+	return NewMapper().Equivalent(a, b)
+}
+
+// Equivalent reports whether a and b map to each other without loss with the rules of the Mapper,
+// along with every mismatch found in both directions. A value absent on one side and zero
+// on the other, like a field a sparse map omits, is not a mismatch
+func (m *Mapper) Equivalent(a, b interface{}) (bool, []Mismatch) {
+	var mismatches []Mismatch
+
+	sameType := a != nil && b != nil && reflect.Indirect(reflect.ValueOf(a)).Type() == reflect.Indirect(reflect.ValueOf(b)).Type()
+	for _, reverse := range []bool{false, true} {
+		from, to := a, b
+		if reverse {
+			if sameType {
+				break
+			}
+			from, to = b, a
+		}
+
+		changes, err := m.Diff(to, from)
+		if errs, ok := err.(Errors); ok {
+			for _, e := range errs {
+				mismatches = append(mismatches, Mismatch{Change{Path: e.Path, New: e.Err, Kind: Unmappable}, reverse})
+			}
+		} else if err != nil {
+			mismatches = append(mismatches, Mismatch{Change{New: err, Kind: Unmappable}, reverse})
+		}
+		for _, c := range changes {
+			if c.Kind == Added && isZeroLeaf(c.New) || c.Kind == Removed && isZeroLeaf(c.Old) {
+				continue
+			}
+			mismatches = append(mismatches, Mismatch{c, reverse})
+		}
+	}
+
+	return len(mismatches) == 0, mismatches
+}
+
+// isZeroLeaf reports whether a leaf value of Flatten is nil, zero or an empty collection
+func isZeroLeaf(v interface{}) bool {
+	val := reflect.ValueOf(v)
+	if !val.IsValid() || val.IsZero() {
+		return true
+	}
+	switch val.Kind() {
+	case reflect.Slice, reflect.Map:
+		return val.Len() == 0
+	}
+	return false
+}
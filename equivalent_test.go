@@ -0,0 +1,66 @@
+package teepr
+
+import (
+	"testing"
+)
+
+func TestEquivalent(t *testing.T) {
+	t.Log("Testing Equivalent values of different types")
+	{
+		item := OrderItem{Id: "itm123", ItemName: "XL 2 Giga", Price: 150000}
+		other := Item{Id: "itm123", ItemName: "XL 2 Giga", Price: 150000}
+
+		if ok, mismatches := Equivalent(item, other); !ok {
+			t.Fatalf("%s expected item and other equivalent, got %+v", failed, mismatches)
+		}
+
+		input := map[string]interface{}{"Id": "itm123", "ItemName": "XL 2 Giga", "Price": 150000.0}
+		if ok, mismatches := Equivalent(input, item); !ok {
+			t.Fatalf("%s expected input and item equivalent, got %+v", failed, mismatches)
+		}
+		t.Logf("%s expected values equivalent", success)
+	}
+
+	t.Log("Testing values that are not Equivalent")
+	{
+		item := OrderItem{Id: "itm123", ItemName: "XL 2 Giga", Price: 150000}
+		lossy := struct {
+			Id       string
+			ItemName string
+		}{"itm123", "XL 3 Giga"}
+
+		ok, mismatches := Equivalent(item, lossy)
+		if ok {
+			t.Fatalf("%s expected item and lossy not equivalent", failed)
+		}
+		if len(mismatches) != 3 {
+			t.Fatalf("%s expected 3 mismatches, got %+v", failed, mismatches)
+		}
+		if m := mismatches[0]; m.Path != "ItemName" || m.Reverse || m.Old != "XL 3 Giga" || m.New != "XL 2 Giga" {
+			t.Fatalf("%s expected ItemName mismatch, got %+v", failed, m)
+		}
+		if m := mismatches[2]; m.Path != "Price" || !m.Reverse || m.Old != 150000.0 || m.New != 0.0 {
+			t.Fatalf("%s expected Price lost on the way back, got %+v", failed, m)
+		}
+		t.Logf("%s Result: %+v", success, mismatches)
+	}
+
+	t.Log("Testing Equivalent struct and sparse map")
+	{
+		user := UserExample{FirstName: "firstex", Authentication: Authentication{Username: "userex"}}
+		input := map[string]interface{}{
+			"FirstName":      "firstex",
+			"Authentication": map[string]interface{}{"Username": "userex"},
+		}
+		if ok, mismatches := Equivalent(user, input); !ok {
+			t.Fatalf("%s expected user and sparse input equivalent, got %+v", failed, mismatches)
+		}
+
+		input["LastName"] = "lastex"
+		ok, mismatches := Equivalent(user, input)
+		if ok || len(mismatches) != 2 || mismatches[0].Path != "LastName" || mismatches[1].Path != "LastName" {
+			t.Fatalf("%s expected LastName mismatch both ways, got %+v", failed, mismatches)
+		}
+		t.Logf("%s Result: %+v", success, mismatches)
+	}
+}
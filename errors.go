@@ -0,0 +1,42 @@
+package teepr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnknownKey  = errors.New("unknown key")
+	ErrUnsetField  = errors.New("field not set")
+	ErrCycle       = errors.New("cycle detected")
+	ErrReadOnly    = errors.New("read-only field")
+	ErrRequired    = errors.New("required field missing")
+	ErrInvalid     = errors.New("invalid value")
+	ErrKeyConflict = errors.New("conflicting key")
+)
+
+// FieldError is an error attached to the dotted path of a value, e.g. "Authentication.Username"
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err.Error())
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors collects every FieldError found during a single mapping
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "[Teepr]" + strings.Join(msgs, "; ")
+}
@@ -0,0 +1,160 @@
+package teepr
+
+import (
+	"reflect"
+	"strings"
+)
+
+// structField is a field of a struct type along with its index sequence,
+// promoted fields of embedded structs have an index longer than one
+type structField struct {
+	reflect.StructField
+	index []int
+}
+
+// structFields lists the exported fields of a struct type used for mapping.
+// Fields of anonymous embedded structs are promoted, following the Go rules
+// on shadowing, unless the embedded field is tagged `teepr:",nosquash"`
+func structFields(typ reflect.Type) []structField {
+	var all []structField
+	collectFields(typ, nil, &all)
+
+	depths := make(map[string]int)
+	counts := make(map[string]int)
+	for _, f := range all {
+		depth, found := depths[f.Name]
+		if !found || len(f.index) < depth {
+			depths[f.Name] = len(f.index)
+			counts[f.Name] = 1
+		} else if len(f.index) == depth {
+			counts[f.Name]++
+		}
+	}
+
+	fields := make([]structField, 0, len(all))
+	for _, f := range all {
+		if len(f.index) == depths[f.Name] && counts[f.Name] == 1 {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func collectFields(typ reflect.Type, index []int, fields *[]structField) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		fIndex := make([]int, len(index)+1)
+		copy(fIndex, index)
+		fIndex[len(index)] = i
+
+		if isSquashed(f) {
+			ftyp := f.Type
+			if ftyp.Kind() == reflect.Ptr {
+				ftyp = ftyp.Elem()
+			}
+			collectFields(ftyp, fIndex, fields)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+		*fields = append(*fields, structField{f, fIndex})
+	}
+}
+
+// isSquashed reports whether the fields of an embedded struct are promoted
+func isSquashed(f reflect.StructField) bool {
+	if !f.Anonymous || hasTagOption(f, "nosquash") {
+		return false
+	}
+
+	ftyp := f.Type
+	if ftyp.Kind() == reflect.Ptr {
+		if f.PkgPath != "" {
+			return false
+		}
+		ftyp = ftyp.Elem()
+	}
+	return ftyp.Kind() == reflect.Struct && !isLeafStruct(ftyp)
+}
+
+// findField looks a field up by its name
+func findField(fields []structField, name string) (structField, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
+
+// fieldByIndex gives the field value of a struct, walking through embedded pointers.
+// A nil embedded pointer is allocated when alloc is true, otherwise the field is reported missing
+func fieldByIndex(val reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				if !alloc || !val.CanSet() {
+					return reflect.Value{}, false
+				}
+				val.Set(reflect.New(val.Type().Elem()))
+			}
+			val = val.Elem()
+		}
+		val = val.Field(x)
+	}
+	return val, true
+}
+
+// findFieldByTag looks a field up by the name given in any of its tags,
+// e.g. a field tagged `json:"first_name"` matches the key first_name
+func findFieldByTag(fields []structField, key string) (structField, bool) {
+	for _, f := range fields {
+		for _, name := range tagNames(f.StructField) {
+			if key == name {
+				return f, true
+			}
+		}
+	}
+	return structField{}, false
+}
+
+// matchFieldByTag looks up the field sharing a tag name with the field in,
+// e.g. `json:"service_name"` matches `ksmg:"service_name"`
+func matchFieldByTag(fields []structField, in reflect.StructField) (structField, bool) {
+	for _, name := range tagNames(in) {
+		if name == "" || name == "-" {
+			continue
+		}
+		if f, found := findFieldByTag(fields, name); found {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
+
+// tagNames gives the names given to a field by its tags
+func tagNames(f reflect.StructField) []string {
+	var names []string
+	for _, split := range strings.Split(string(f.Tag), " ") {
+		osplit := strings.Split(split, ":")
+		if len(osplit) == 2 {
+			names = append(names, strings.Replace(strings.Split(osplit[1], ",")[0], "\"", "", -1))
+		}
+	}
+	return names
+}
+
+var remainType = reflect.TypeOf(map[string]interface{}{})
+
+// remainField gives the field tagged `teepr:",remain"` collecting the map keys
+// that match no other field
+func remainField(fields []structField) (structField, bool) {
+	for _, f := range fields {
+		if f.Type == remainType && hasTagOption(f.StructField, "remain") {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
@@ -0,0 +1,210 @@
+package teepr
+
+import (
+	"testing"
+	"time"
+)
+
+type BaseEntity struct {
+	CreatedBy string     `gorm:"created_by"`
+	CreatedAt *time.Time `gorm:"created_at"`
+	UpdatedBy string     `gorm:"updated_by"`
+}
+
+type AuditEntity struct {
+	Version int `gorm:"version"`
+}
+
+type EmbeddedMenuEntity struct {
+	BaseEntity
+	*AuditEntity
+	Name string `gorm:"name"`
+}
+
+type NoSquashMenuEntity struct {
+	BaseEntity `teepr:",nosquash"`
+	Name       string
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	t.Log("Testing map input, output struct with embedded structs")
+	{
+		input := map[string]interface{}{
+			"name":       "Menu",
+			"CreatedBy":  "user1",
+			"updated_by": "user2",
+			"version":    float64(3),
+		}
+
+		output := EmbeddedMenuEntity{}
+		err := Teepr(input, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		if output.Name != "Menu" || output.CreatedBy != "user1" || output.UpdatedBy != "user2" {
+			t.Fatalf("%s expected Name = Menu, CreatedBy = user1, UpdatedBy = user2, got %+v", failed, output)
+		}
+		if output.AuditEntity == nil || output.Version != 3 {
+			t.Fatalf("%s expected embedded AuditEntity allocated with Version = 3, got %+v", failed, output.AuditEntity)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing struct with embedded structs input, output flat struct")
+	{
+		now := time.Now()
+		input := EmbeddedMenuEntity{
+			BaseEntity: BaseEntity{CreatedBy: "user1", CreatedAt: &now},
+			Name:       "Menu",
+		}
+
+		output := struct {
+			Name      string
+			CreatedBy string
+			CreatedAt *time.Time
+			Version   int
+		}{}
+		err := Teepr(input, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Name != "Menu" || output.CreatedBy != "user1" || output.CreatedAt == nil || !output.CreatedAt.Equal(now) {
+			t.Fatalf("%s expected Name = Menu, CreatedBy = user1, CreatedAt = %v, got %+v", failed, now, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+
+		back := EmbeddedMenuEntity{}
+		err = Teepr(struct {
+			Name    string
+			Version int
+		}{"Menu", 2}, &back)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if back.AuditEntity == nil || back.Version != 2 {
+			t.Fatalf("%s expected embedded AuditEntity allocated with Version = 2, got %+v", failed, back.AuditEntity)
+		}
+		t.Logf("%s Result: %+v", success, back)
+	}
+
+	t.Log("Testing struct with embedded structs input, output map")
+	{
+		input := EmbeddedMenuEntity{
+			BaseEntity:  BaseEntity{CreatedBy: "user1"},
+			AuditEntity: &AuditEntity{Version: 1},
+			Name:        "Menu",
+		}
+
+		output := map[string]interface{}{}
+		err := Teepr(input, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output["CreatedBy"] != "user1" || output["Version"] != 1 {
+			t.Fatalf("%s expected CreatedBy = user1, Version = 1, got %v", failed, output)
+		}
+		if _, found := output["BaseEntity"]; found {
+			t.Fatalf("%s expected BaseEntity is squashed, got %v", failed, output)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing embedded struct tagged nosquash")
+	{
+		input := map[string]interface{}{
+			"Name":       "Menu",
+			"CreatedBy":  "user1",
+			"BaseEntity": map[string]interface{}{"CreatedBy": "user2"},
+		}
+
+		output := NoSquashMenuEntity{}
+		err := Teepr(input, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.CreatedBy != "user2" {
+			t.Fatalf("%s expected CreatedBy = user2, got %s", failed, output.CreatedBy)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+}
+
+type OrderCreatedPayload struct {
+	ID     string                 `json:"id"`
+	Status string                 `json:"status"`
+	Extra  map[string]interface{} `teepr:",remain"`
+}
+
+func TestRemainField(t *testing.T) {
+	t.Log("Testing map input, output struct with remain field")
+	{
+		input := map[string]interface{}{
+			"id":          "000000010",
+			"status":      "Order Created",
+			"channel":     "mobile",
+			"device_id":   "5566478997710",
+			"total_price": float64(25000),
+			"Extra":       "not the remain field",
+		}
+
+		output := OrderCreatedPayload{}
+		err := Teepr(input, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		if output.ID != "000000010" || output.Status != "Order Created" {
+			t.Fatalf("%s expected ID = 000000010, Status = Order Created, got %+v", failed, output)
+		}
+		if len(output.Extra) != 4 || output.Extra["channel"] != "mobile" || output.Extra["total_price"] != float64(25000) {
+			t.Fatalf("%s expected Extra holds the 4 unmatched keys, got %v", failed, output.Extra)
+		}
+		t.Logf("%s Result: %+v", success, output)
+
+		republished := map[string]interface{}{}
+		err = Teepr(output, &republished)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if republished["device_id"] != "5566478997710" || republished["ID"] != "000000010" {
+			t.Fatalf("%s expected remain keys inlined in the output map, got %v", failed, republished)
+		}
+		if _, found := republished["Extra"]; !found {
+			t.Fatalf("%s expected the captured Extra key inlined in the output map, got %v", failed, republished)
+		}
+		t.Logf("%s Result: %v", success, republished)
+	}
+}
+
+type TaggedProfileInput struct {
+	Name     string `teepr:",required"`
+	Nickname string `teepr:",required"`
+	Handle   string `json:"handle" teepr:",trim"`
+}
+
+type TaggedProfileOutput struct {
+	Name     string `teepr:",required"`
+	Title    string `teepr:",required"`
+	UserName string `bson:"handle"`
+}
+
+func TestStructTagMatch(t *testing.T) {
+	t.Log("Testing struct input matches output fields by tag names, not by shared tag options")
+	{
+		input := TaggedProfileInput{Name: "n", Nickname: "nick", Handle: "userex"}
+		var output TaggedProfileOutput
+		err := Teepr(input, &output)
+		errs, _ := err.(Errors)
+		if len(errs) != 1 || !hasFieldError(errs, "Title", ErrRequired) {
+			t.Fatalf("%s expected only Title reported missing, got %v", failed, err)
+		}
+		if output.Name != "n" || output.Title != "" {
+			t.Fatalf("%s expected Name = n and Title empty, got %+v", failed, output)
+		}
+		if output.UserName != "userex" {
+			t.Fatalf("%s expected UserName = userex, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+}
@@ -0,0 +1,231 @@
+package teepr
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FlattenSeparator = "."
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// Flatten turns a nested struct or map into a flat map whose keys are the dotted
+// path of every leaf value, e.g. "Authentication.ServiceDetail.Cost".
+// Slice elements are addressed by their index, e.g. "Items.0.Price".
+// A reference back to a value being flattened, like the parent of a child, is kept as a leaf
+func Flatten(in interface{}) map[string]interface{} {
+	f := newFlattener()
+	if in != nil {
+		f.flatten(reflect.ValueOf(in), "")
+	}
+	return f.result
+}
+
+// flattener holds the state of a single Flatten
+type flattener struct {
+	result map[string]interface{}
+	stack  map[visit]bool
+	// cycles are the paths of the leaves holding a reference back to a value being flattened
+	cycles []string
+}
+
+func newFlattener() *flattener {
+	return &flattener{result: make(map[string]interface{}), stack: make(map[visit]bool)}
+}
+
+// enter records a reference in the traversal path, it returns false when the reference
+// is already there, the value is then kept as a leaf
+func (f *flattener) enter(val reflect.Value, prefix string, key visit) bool {
+	if f.stack[key] {
+		f.cycles = append(f.cycles, prefix)
+		if prefix != "" {
+			f.result[prefix] = val.Interface()
+		}
+		return false
+	}
+	f.stack[key] = true
+	return true
+}
+
+func (f *flattener) flatten(val reflect.Value, prefix string) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			if prefix != "" {
+				f.result[prefix] = nil
+			}
+			return
+		}
+		if val.Kind() == reflect.Ptr {
+			key := visit{val.Pointer(), val.Type(), 0}
+			if !f.enter(val, prefix, key) {
+				return
+			}
+			defer delete(f.stack, key)
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		if isLeafStruct(val.Type()) {
+			break
+		}
+		for _, fl := range structFields(val.Type()) {
+			if fval, ok := fieldByIndex(val, fl.index, false); ok {
+				f.flatten(fval, joinPath(prefix, fl.Name))
+			}
+		}
+		return
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String || val.Len() == 0 {
+			break
+		}
+		key := visit{val.Pointer(), val.Type(), 0}
+		if !f.enter(val, prefix, key) {
+			return
+		}
+		defer delete(f.stack, key)
+		for _, k := range val.MapKeys() {
+			f.flatten(val.MapIndex(k), joinPath(prefix, k.String()))
+		}
+		return
+	case reflect.Slice:
+		if val.Type().Elem().Kind() == reflect.Uint8 || val.Len() == 0 {
+			break
+		}
+		key := visit{val.Pointer(), val.Type(), val.Len()}
+		if !f.enter(val, prefix, key) {
+			return
+		}
+		defer delete(f.stack, key)
+		for i := 0; i < val.Len(); i++ {
+			f.flatten(val.Index(i), joinPath(prefix, strconv.Itoa(i)))
+		}
+		return
+	}
+
+	if prefix != "" {
+		f.result[prefix] = val.Interface()
+	}
+}
+
+// cycleErrors reports every reference back to a value being flattened as ErrCycle
+func (f *flattener) cycleErrors() error {
+	if len(f.cycles) == 0 {
+		return nil
+	}
+	sort.Strings(f.cycles)
+	errs := make(Errors, len(f.cycles))
+	for i, path := range f.cycles {
+		errs[i] = &FieldError{Path: path, Err: ErrCycle}
+	}
+	return errs
+}
+
+// Unflatten rebuilds the nested structure described by the dotted keys of in,
+// then maps it into out using the same conversion rules as Teepr.
+// A key holding a value as well as nested keys, like "a" and "a.b", fails with ErrKeyConflict
+func Unflatten(in map[string]interface{}, out interface{}, customValues ...func(interface{}) (interface{}, error)) error {
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	nested := make(map[string]interface{})
+	for _, k := range keys {
+		parts := strings.Split(k, FlattenSeparator)
+		current := nested
+		for i, p := range parts[:len(parts)-1] {
+			v, found := current[p]
+			next, ok := v.(map[string]interface{})
+			if found && !ok {
+				return &FieldError{Path: strings.Join(parts[:i+1], FlattenSeparator), Err: ErrKeyConflict}
+			}
+			if !found {
+				next = make(map[string]interface{})
+				current[p] = next
+			}
+			current = next
+		}
+		if _, found := current[parts[len(parts)-1]]; found {
+			return &FieldError{Path: k, Err: ErrKeyConflict}
+		}
+		current[parts[len(parts)-1]] = in[k]
+	}
+
+	return Teepr(indexedToSlice(nested, reflect.TypeOf(out)), out, customValues...)
+}
+
+// indexedToSlice converts back into a slice every nested map whose keys are exactly 0..n-1
+// and whose output value, found in typ, is a slice or an array
+func indexedToSlice(v interface{}, typ reflect.Type) interface{} {
+	in, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	for k, e := range in {
+		in[k] = indexedToSlice(e, elemType(typ, k))
+	}
+
+	if len(in) == 0 || typ == nil || typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+		return in
+	}
+	result := make([]interface{}, len(in))
+	for k, e := range in {
+		idx, err := strconv.Atoi(k)
+		if err != nil || idx < 0 || idx >= len(in) {
+			return in
+		}
+		result[idx] = e
+	}
+	return result
+}
+
+// elemType gives the type of the value at key of a value of type typ, nil when unknown
+func elemType(typ reflect.Type, key string) reflect.Type {
+	if typ == nil {
+		return nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		fields := structFields(typ)
+		f, found := findField(fields, key)
+		if !found {
+			f, found = findFieldByTag(fields, key)
+		}
+		if found {
+			return f.Type
+		}
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return typ.Elem()
+	}
+	return nil
+}
+
+// isLeafStruct reports whether a struct type is handled as a single value by the
+// conversion rules, like time.Time and the sql.Null* types
+func isLeafStruct(typ reflect.Type) bool {
+	return typ == timeType || typ.Implements(valuerType)
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + FlattenSeparator + name
+}
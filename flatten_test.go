@@ -0,0 +1,125 @@
+package teepr
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFlattenUnflatten(t *testing.T) {
+	userEx := UserExample{
+		FirstName: "firstex",
+		LastName:  "lastex",
+		Email:     "firstduo@example.com",
+		Title:     "title",
+		Authentication: Authentication{
+			Username:  "xiexample",
+			APISecret: "apisecret",
+			APIToken:  "apitoken",
+			ServiceDetail: ServiceDetail{
+				Service: "aservicenm",
+				Cost:    15000.00,
+			},
+		},
+		CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Log("Testing Flatten nested struct")
+	{
+		flat := Flatten(userEx)
+
+		if flat["Authentication.ServiceDetail.Cost"] != 15000.00 {
+			t.Fatalf("%s expected Authentication.ServiceDetail.Cost = 15000, got %v", failed, flat["Authentication.ServiceDetail.Cost"])
+		}
+		if flat["Authentication.Username"] != "xiexample" {
+			t.Fatalf("%s expected Authentication.Username = xiexample, got %v", failed, flat["Authentication.Username"])
+		}
+		if _, ok := flat["CreatedAt"].(time.Time); !ok {
+			t.Fatalf("%s expected CreatedAt is a time.Time, got %T", failed, flat["CreatedAt"])
+		}
+		t.Logf("%s Result: %v", success, flat)
+
+		output := UserExample{}
+		err := Unflatten(flat, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if !reflect.DeepEqual(userEx, output) {
+			t.Fatalf("%s expected output = %v, got %v", failed, userEx, output)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing Flatten struct with slice")
+	{
+		order := OrderEx{
+			Id:     "o123",
+			Status: "OrderCreated",
+			Items: []OrderItem{
+				{Id: "itm123", ItemName: "XL 2 Giga", Price: 150000},
+				{Id: "itm124", ItemName: "XL 5 Giga", Price: 300000},
+			},
+		}
+
+		flat := Flatten(order)
+		if flat["Items.1.Price"] != 300000.0 {
+			t.Fatalf("%s expected Items.1.Price = 300000, got %v", failed, flat["Items.1.Price"])
+		}
+
+		output := OrderEx{}
+		err := Unflatten(flat, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if !reflect.DeepEqual(order, output) {
+			t.Fatalf("%s expected output = %v, got %v", failed, order, output)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing Unflatten keeps maps with index-like keys")
+	{
+		type Scoreboard struct {
+			Name   string
+			Scores map[string]int
+		}
+		board := Scoreboard{Name: "board", Scores: map[string]int{"0": 5, "1": 6}}
+
+		output := Scoreboard{}
+		err := Unflatten(Flatten(board), &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if !reflect.DeepEqual(board, output) {
+			t.Fatalf("%s expected output = %v, got %v", failed, board, output)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing Unflatten fails on conflicting keys")
+	{
+		output := map[string]interface{}{}
+		err := Unflatten(map[string]interface{}{"a": 1, "a.b": 2}, &output)
+		fieldErr, ok := err.(*FieldError)
+		if !ok || fieldErr.Err != ErrKeyConflict || fieldErr.Path != "a" {
+			t.Fatalf("%s expected ErrKeyConflict at a, got %v", failed, err)
+		}
+		t.Logf("%s Result: %s", success, err.Error())
+	}
+
+	t.Log("Testing Flatten self-referencing graph")
+	{
+		root := &MenuNode{Name: "root"}
+		child := &MenuNode{Name: "child", Parent: root}
+		root.Children = []*MenuNode{child}
+
+		flat := Flatten(root)
+		if flat["Name"] != "root" || flat["Children.0.Name"] != "child" {
+			t.Fatalf("%s expected Name = root, Children.0.Name = child, got %v", failed, flat)
+		}
+		if flat["Children.0.Parent"] != root {
+			t.Fatalf("%s expected Children.0.Parent kept as a leaf holding root, got %v", failed, flat["Children.0.Parent"])
+		}
+		t.Logf("%s Result: %v", success, flat)
+	}
+}
@@ -0,0 +1,110 @@
+package teepr
+
+import (
+	"reflect"
+)
+
+// BeforeHook is implemented by output types preparing themselves before their fields
+// are mapped from src, an error leaves the fields unmapped
+type BeforeHook interface {
+	BeforeTeepr(src interface{}) error
+}
+
+// AfterHook is implemented by output types completing themselves, e.g. computing
+// derived fields, once their fields are mapped from src
+type AfterHook interface {
+	AfterTeepr(src interface{}) error
+}
+
+// HookFunc is a hook registered on a Mapper for a pair of types, dst is a pointer
+// to the output struct
+type HookFunc func(src, dst interface{}) error
+
+type hookKey struct {
+	src, dst reflect.Type
+}
+
+// WithBeforeHook registers fn to be called before a struct of the type of dst is mapped
+// from a value of the type of src. Pointers are dereferenced, also in the src given to fn.
+// An error leaves the fields unmapped
+func WithBeforeHook(src, dst interface{}, fn HookFunc) Option {
+	return func(m *Mapper) {
+		if m.before == nil {
+			m.before = make(map[hookKey][]HookFunc)
+		}
+		key := newHookKey(src, dst)
+		m.before[key] = append(m.before[key], fn)
+	}
+}
+
+// WithAfterHook registers fn to be called once a struct of the type of dst is mapped
+// from a value of the type of src, pointers are dereferenced
+func WithAfterHook(src, dst interface{}, fn HookFunc) Option {
+	return func(m *Mapper) {
+		if m.after == nil {
+			m.after = make(map[hookKey][]HookFunc)
+		}
+		key := newHookKey(src, dst)
+		m.after[key] = append(m.after[key], fn)
+	}
+}
+
+func newHookKey(src, dst interface{}) hookKey {
+	return hookKey{indirectType(reflect.TypeOf(src)), indirectType(reflect.TypeOf(dst))}
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// beforeHooks calls the before hooks of the struct oval, it returns false
+// when one of them fails, the error is attached to path. When the steps are run
+// on the final output, the hooks are called once for each path
+func (it *iteration) beforeHooks(input interface{}, oval reflect.Value, path string) bool {
+	if !oval.CanAddr() || it.finish == finishNever || it.finish == finishLater && it.hooked[path] {
+		return true
+	}
+	if it.finish == finishLater {
+		if it.hooked == nil {
+			it.hooked = make(map[string]bool)
+		}
+		it.hooked[path] = true
+	}
+	dst := oval.Addr().Interface()
+
+	if hook, ok := dst.(BeforeHook); ok {
+		if err := hook.BeforeTeepr(input); err != nil {
+			it.addError(path, err)
+			return false
+		}
+	}
+	for _, fn := range it.before[hookKey{indirectType(reflect.TypeOf(input)), oval.Type()}] {
+		if err := fn(input, dst); err != nil {
+			it.addError(path, err)
+			return false
+		}
+	}
+	return true
+}
+
+// afterHooks calls the after hooks of the struct oval, errors are attached to path
+func (it *iteration) afterHooks(input interface{}, oval reflect.Value, path string) {
+	if !oval.CanAddr() {
+		return
+	}
+	dst := oval.Addr().Interface()
+
+	if hook, ok := dst.(AfterHook); ok {
+		if err := hook.AfterTeepr(input); err != nil {
+			it.addError(path, err)
+		}
+	}
+	for _, fn := range it.after[hookKey{indirectType(reflect.TypeOf(input)), oval.Type()}] {
+		if err := fn(input, dst); err != nil {
+			it.addError(path, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package teepr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var errMissingId = errors.New("missing id")
+
+type PricedOrder struct {
+	Id         string
+	Items      []OrderItem
+	TotalPrice float64
+	Source     string
+}
+
+func (o *PricedOrder) BeforeTeepr(src interface{}) error {
+	o.Source = fmt.Sprintf("%T", src)
+	return nil
+}
+
+func (o *PricedOrder) AfterTeepr(src interface{}) error {
+	o.TotalPrice = 0
+	for _, item := range o.Items {
+		o.TotalPrice += item.Price
+	}
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	input := OrderEx{
+		Id: "ord123",
+		Items: []OrderItem{
+			{Id: "itm1", ItemName: "XL 2 Giga", Price: 150000},
+			{Id: "itm2", ItemName: "XL 5 Giga", Price: 250000},
+		},
+	}
+
+	t.Log("Testing BeforeTeepr and AfterTeepr methods of the output")
+	{
+		var output PricedOrder
+		if err := Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.TotalPrice != 400000 || output.Source != "teepr.OrderEx" {
+			t.Fatalf("%s expected TotalPrice = 400000 and Source = teepr.OrderEx, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing hooks registered for a pair of types")
+	{
+		mapper := NewMapper(
+			WithAfterHook(OrderEx{}, PricedOrder{}, func(src, dst interface{}) error {
+				dst.(*PricedOrder).Id = strings.ToUpper(src.(OrderEx).Id)
+				return nil
+			}),
+			WithBeforeHook(map[string]interface{}{}, PricedOrder{}, func(src, dst interface{}) error {
+				if _, ok := src.(map[string]interface{})["Id"]; !ok {
+					return errMissingId
+				}
+				return nil
+			}),
+		)
+
+		var output PricedOrder
+		if err := mapper.Teepr(&input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Id != "ORD123" {
+			t.Fatalf("%s expected Id = ORD123, got %s", failed, output.Id)
+		}
+
+		output = PricedOrder{}
+		errs, _ := mapper.Teepr(map[string]interface{}{"TotalPrice": 1.0}, &output).(Errors)
+		if len(errs) != 1 || !hasFieldError(errs, "", errMissingId) || output.TotalPrice != 0 {
+			t.Fatalf("%s expected the failed before hook leaves the output unmapped, got %v %+v", failed, errs, output)
+		}
+		t.Logf("%s Result: %s", success, errs.Error())
+	}
+}
@@ -0,0 +1,428 @@
+package teepr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrPathNotFound = errors.New("path not found")
+	ErrTestFailed   = errors.New("test failed")
+	ErrInvalidOp    = errors.New("invalid operation")
+)
+
+// Operation is a JSON patch (RFC 6902) operation, paths are JSON pointers (RFC 6901)
+// whose tokens are field names, tag names, map keys or slice indexes
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ApplyPatch applies the JSON patch operations in order onto out, operation values
+// are converted with the rules of Teepr. Either every operation is applied or none is
+func ApplyPatch(ops []Operation, out interface{}) error {
+	return NewMapper().ApplyPatch(ops, out)
+}
+
+// ApplyPatch applies the JSON patch operations in order onto out with the options of the Mapper.
+// Fields outside of a field mask are left untouched, and writes to protected fields
+// of untrusted input are ignored or rejected
+func (m *Mapper) ApplyPatch(ops []Operation, out interface{}) error {
+	oval := reflect.ValueOf(out)
+	if oval.Kind() != reflect.Ptr || oval.IsNil() {
+		return fmt.Errorf("[Teepr]expecting output of type pointer, got %T", out)
+	}
+
+	doc := reflect.New(oval.Elem().Type()).Elem()
+	doc.Set(reflect.ValueOf(Clone(oval.Elem().Interface())))
+
+	p := &patcher{it: &iteration{Mapper: m, collection: m.collection, collectionKey: m.collectionKey}}
+	for _, op := range ops {
+		if err := p.apply(doc, op); err != nil {
+			return err
+		}
+	}
+	if len(p.it.errs) > 0 {
+		return p.it.errs
+	}
+
+	oval.Elem().Set(doc)
+	return nil
+}
+
+type patcher struct {
+	it *iteration
+}
+
+func (p *patcher) apply(doc reflect.Value, op Operation) error {
+	switch op.Op {
+	case "add", "replace":
+		return p.walk(doc, op.Path, true, func(parent reflect.Value, token, path string) error {
+			return p.set(parent, token, path, op.Value, op.Op == "add")
+		})
+	case "remove":
+		return p.walk(doc, op.Path, false, p.remove)
+	case "test":
+		current, err := p.get(doc, op.Path)
+		if err != nil {
+			return err
+		}
+		expected, err := p.convertTest(op.Value, current.Type())
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(expected.Interface(), current.Interface()) {
+			return &FieldError{Path: op.Path, Err: ErrTestFailed}
+		}
+		return nil
+	case "move", "copy":
+		if op.Op == "move" && strings.HasPrefix(op.Path, op.From+"/") {
+			return &FieldError{Path: op.Path, Err: ErrInvalidOp}
+		}
+		value, err := p.get(doc, op.From)
+		if err != nil {
+			return err
+		}
+		moved := Clone(value.Interface())
+		if op.Op == "move" {
+			if err := p.walk(doc, op.From, false, p.remove); err != nil {
+				return err
+			}
+		}
+		return p.walk(doc, op.Path, true, func(parent reflect.Value, token, path string) error {
+			return p.set(parent, token, path, moved, true)
+		})
+	}
+
+	return &FieldError{Path: op.Path, Err: ErrInvalidOp}
+}
+
+// walk follows the JSON pointer from v and calls fn on the parent of the last token,
+// path is the dotted path of the value the pointer refers to. Nil pointers on the way
+// are allocated when alloc is true, otherwise the pointer is not found.
+// Values held by maps and interfaces are copied and written back once fn is done
+func (p *patcher) walk(v reflect.Value, pointer string, alloc bool, fn func(parent reflect.Value, token, path string) error) error {
+	if pointer == "" {
+		root := reflect.New(reflect.StructOf([]reflect.StructField{{Name: "Root", Type: v.Type()}})).Elem()
+		root.Field(0).Set(v)
+		if err := fn(root, "Root", ""); err != nil {
+			return err
+		}
+		v.Set(root.Field(0))
+		return nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return &FieldError{Path: pointer, Err: ErrPathNotFound}
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	err := p.walkTokens(v, tokens, "", alloc, fn)
+	if err == ErrPathNotFound || err == ErrInvalidOp {
+		return &FieldError{Path: pointer, Err: err}
+	}
+	return err
+}
+
+func (p *patcher) walkTokens(v reflect.Value, tokens []string, path string, alloc bool, fn func(parent reflect.Value, token, path string) error) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if !alloc || !v.CanSet() {
+				return ErrPathNotFound
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return p.walkTokens(v.Elem(), tokens, path, alloc, fn)
+	case reflect.Interface:
+		if v.IsNil() {
+			return ErrPathNotFound
+		}
+		tmp := reflect.New(v.Elem().Type()).Elem()
+		tmp.Set(v.Elem())
+		if err := p.walkTokens(tmp, tokens, path, alloc, fn); err != nil {
+			return err
+		}
+		v.Set(tmp)
+		return nil
+	}
+
+	if len(tokens) == 1 {
+		segment := tokens[0]
+		if v.Kind() == reflect.Struct {
+			if _, f, ok := p.field(v, tokens[0], alloc); ok {
+				segment = f.Name
+			}
+		} else if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && segment == "-" {
+			segment = strconv.Itoa(v.Len())
+		}
+		return fn(v, tokens[0], joinPath(path, segment))
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		child, f, ok := p.field(v, tokens[0], alloc)
+		if !ok {
+			return ErrPathNotFound
+		}
+		return p.walkTokens(child, tokens[1:], joinPath(path, f.Name), alloc, fn)
+	case reflect.Map:
+		key, ok := mapKey(v, tokens[0])
+		if !ok || !v.MapIndex(key).IsValid() {
+			return ErrPathNotFound
+		}
+		tmp := reflect.New(v.Type().Elem()).Elem()
+		tmp.Set(v.MapIndex(key))
+		if err := p.walkTokens(tmp, tokens[1:], joinPath(path, tokens[0]), alloc, fn); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, tmp)
+		return nil
+	case reflect.Slice, reflect.Array:
+		idx, err := strconv.Atoi(tokens[0])
+		if err != nil || idx < 0 || idx >= v.Len() {
+			return ErrPathNotFound
+		}
+		return p.walkTokens(v.Index(idx), tokens[1:], joinPath(path, tokens[0]), alloc, fn)
+	}
+
+	return ErrPathNotFound
+}
+
+// get gives the value the JSON pointer refers to, without allocating anything on the way
+func (p *patcher) get(doc reflect.Value, pointer string) (reflect.Value, error) {
+	var value reflect.Value
+	err := p.walk(doc, pointer, false, func(parent reflect.Value, token, _ string) error {
+		switch parent.Kind() {
+		case reflect.Struct:
+			if f, _, ok := p.field(parent, token, false); ok {
+				value = f
+			}
+		case reflect.Map:
+			if key, ok := mapKey(parent, token); ok {
+				value = parent.MapIndex(key)
+			}
+		case reflect.Slice, reflect.Array:
+			if idx, err := strconv.Atoi(token); err == nil && idx >= 0 && idx < parent.Len() {
+				value = parent.Index(idx)
+			}
+		}
+		if !value.IsValid() {
+			return ErrPathNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return value, err
+	}
+	for value.Kind() == reflect.Interface && !value.IsNil() {
+		value = value.Elem()
+	}
+	return value, nil
+}
+
+// writable reports whether the value at path may be written, writes outside of the field mask
+// are skipped and writes to protected fields are reported
+func (p *patcher) writable(field reflect.StructField, path string) bool {
+	if !p.it.inMask(path) {
+		return false
+	}
+	if p.it.isProtected(field, path) {
+		p.it.protectedWrite(path)
+		return false
+	}
+	return true
+}
+
+// set writes the converted value at token of parent, insert tells whether a slice
+// element is inserted (add) or replaced (replace)
+func (p *patcher) set(parent reflect.Value, token, path string, value interface{}, insert bool) error {
+	switch parent.Kind() {
+	case reflect.Struct:
+		f, sf, ok := p.field(parent, token, true)
+		if !ok {
+			return ErrPathNotFound
+		}
+		if !p.writable(sf.StructField, path) {
+			return nil
+		}
+		cval, err := p.convert(value, f, path)
+		if err != nil {
+			return err
+		}
+		f.Set(cval)
+		return nil
+	case reflect.Map:
+		key, ok := mapKey(parent, token)
+		if !ok || !insert && !parent.MapIndex(key).IsValid() {
+			return ErrPathNotFound
+		}
+		if !p.writable(reflect.StructField{}, path) {
+			return nil
+		}
+		current := reflect.New(parent.Type().Elem()).Elem()
+		if existing := parent.MapIndex(key); existing.IsValid() {
+			current.Set(existing)
+		}
+		cval, err := p.convert(value, current, path)
+		if err != nil {
+			return err
+		}
+		if parent.IsNil() {
+			parent.Set(reflect.MakeMap(parent.Type()))
+		}
+		parent.SetMapIndex(key, cval)
+		return nil
+	case reflect.Slice, reflect.Array:
+		idx := parent.Len()
+		if token != "-" || !insert || parent.Kind() == reflect.Array {
+			var err error
+			idx, err = strconv.Atoi(token)
+			if err != nil || idx < 0 || idx > parent.Len() || idx == parent.Len() && !insert {
+				return ErrPathNotFound
+			}
+		}
+		if !p.writable(reflect.StructField{}, path) {
+			return nil
+		}
+		current := reflect.New(parent.Type().Elem()).Elem()
+		if !insert {
+			current.Set(parent.Index(idx))
+		}
+		cval, err := p.convert(value, current, path)
+		if err != nil {
+			return err
+		}
+		if !insert {
+			parent.Index(idx).Set(cval)
+			return nil
+		}
+		if parent.Kind() == reflect.Array {
+			return ErrInvalidOp
+		}
+		parent.Set(reflect.Append(parent, cval))
+		reflect.Copy(parent.Slice(idx+1, parent.Len()), parent.Slice(idx, parent.Len()-1))
+		parent.Index(idx).Set(cval)
+		return nil
+	}
+
+	return ErrPathNotFound
+}
+
+func (p *patcher) remove(parent reflect.Value, token, path string) error {
+	switch parent.Kind() {
+	case reflect.Struct:
+		f, sf, ok := p.field(parent, token, false)
+		if !ok {
+			return ErrPathNotFound
+		}
+		if p.writable(sf.StructField, path) {
+			f.Set(reflect.Zero(f.Type()))
+		}
+		return nil
+	case reflect.Map:
+		key, ok := mapKey(parent, token)
+		if !ok || !parent.MapIndex(key).IsValid() {
+			return ErrPathNotFound
+		}
+		if p.writable(reflect.StructField{}, path) {
+			parent.SetMapIndex(key, reflect.Value{})
+		}
+		return nil
+	case reflect.Slice:
+		idx, err := strconv.Atoi(token)
+		if err != nil || idx < 0 || idx >= parent.Len() {
+			return ErrPathNotFound
+		}
+		if p.writable(reflect.StructField{}, path) {
+			reflect.Copy(parent.Slice(idx, parent.Len()), parent.Slice(idx+1, parent.Len()))
+			parent.Set(parent.Slice(0, parent.Len()-1))
+		}
+		return nil
+	}
+
+	return ErrPathNotFound
+}
+
+// convert maps an operation value into a value of the type of current with the rules of Teepr,
+// at the path of the value so the field mask and the protected fields apply below it.
+// The value is mapped onto current when only part of it may be written
+func (p *patcher) convert(value interface{}, current reflect.Value, path string) (reflect.Value, error) {
+	typ := current.Type()
+	if value == nil {
+		return reflect.Zero(typ), nil
+	}
+	restricted := p.it.untrusted || len(p.it.fields) > 0 || p.it.profile != ""
+	if v := reflect.ValueOf(value); v.Type().AssignableTo(typ) && (!restricted || typ.Kind() == reflect.Interface) {
+		return v, nil
+	}
+
+	out := reflect.New(typ)
+	if p.it.untrusted || p.it.partlyMasked(path) {
+		out.Elem().Set(current)
+	}
+	if err := p.it.teepr(value, out.Interface(), path); err != nil {
+		return out.Elem(), err
+	}
+	return out.Elem(), nil
+}
+
+// convertTest maps the value of a test operation into a value of typ, the field mask
+// and the protected fields do not apply since nothing is written
+func (p *patcher) convertTest(value interface{}, typ reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(typ), nil
+	}
+	if v := reflect.ValueOf(value); v.Type().AssignableTo(typ) {
+		return v, nil
+	}
+
+	m := *p.it.Mapper
+	m.untrusted, m.fields = false, nil
+	out := reflect.New(typ)
+	if err := m.Teepr(value, out.Interface()); err != nil {
+		return out.Elem(), err
+	}
+	return out.Elem(), nil
+}
+
+// field looks up the field of a struct a JSON pointer token refers to, by its name
+// in the profile of the Mapper, its teepr name, its field name or any of its tag names.
+// Nil embedded pointers are allocated when alloc is true
+func (p *patcher) field(v reflect.Value, token string, alloc bool) (reflect.Value, structField, bool) {
+	fields := p.it.profileFields(v.Type())
+
+	f, found := structField{}, false
+	for _, sf := range fields {
+		if p.it.keyOf(sf.StructField) == token {
+			f, found = sf, true
+			break
+		}
+	}
+	if !found {
+		f, found = findField(fields, token)
+	}
+	if !found {
+		f, found = findFieldByTag(fields, token)
+	}
+	if !found {
+		return reflect.Value{}, f, false
+	}
+	fval, ok := fieldByIndex(v, f.index, alloc)
+	return fval, f, ok
+}
+
+func mapKey(v reflect.Value, token string) (reflect.Value, bool) {
+	if v.Type().Key().Kind() != reflect.String {
+		return reflect.Value{}, false
+	}
+	return reflect.ValueOf(token).Convert(v.Type().Key()), true
+}
@@ -0,0 +1,210 @@
+package teepr
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	order := func() Order {
+		return Order{
+			ID:     "000000010",
+			Status: "Order Created",
+			OrderItems: []OrderItemOp{
+				{ItemID: 15540, Name: "XL 5 giga", Price: 25000, Quantity: 2},
+				{ItemID: 15541, Name: "Telkomsel flash 5 giga", Price: 25000, Quantity: 1},
+			},
+		}
+	}
+
+	t.Log("Testing JSON patch operations onto a struct")
+	{
+		body := `[
+			{"op": "test", "path": "/status", "value": "Order Created"},
+			{"op": "replace", "path": "/status", "value": "Order Paid"},
+			{"op": "replace", "path": "/order_items/0/quantity", "value": 3},
+			{"op": "add", "path": "/order_items/1", "value": {"item_id": 15542, "name": "Indosat 1 giga", "price": 10000}},
+			{"op": "remove", "path": "/order_items/2"},
+			{"op": "copy", "from": "/id", "path": "/campaign_id"},
+			{"op": "move", "from": "/device_id", "path": "/channel"}
+		]`
+		var ops []Operation
+		if err := json.Unmarshal([]byte(body), &ops); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		target := order()
+		target.DeviceID = "5566478997710"
+
+		err := ApplyPatch(ops[:5], &target)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if target.Status != "Order Paid" || target.OrderItems[0].Quantity != 3 {
+			t.Fatalf("%s expected Status and quantity replaced, got %+v", failed, target)
+		}
+		if len(target.OrderItems) != 2 || target.OrderItems[1].ItemID != 15542 || target.OrderItems[1].Price != 10000 {
+			t.Fatalf("%s expected item 15542 inserted and the last item removed, got %+v", failed, target.OrderItems)
+		}
+
+		err = ApplyPatch(ops[5:], &target)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if target.CampaignID != "000000010" || target.Channel != "5566478997710" || target.DeviceID != "" {
+			t.Fatalf("%s expected id copied to campaign_id and device_id moved to channel, got %+v", failed, target)
+		}
+		t.Logf("%s Result: %+v", success, target)
+	}
+
+	t.Log("Testing JSON patch operations onto a map and a slice")
+	{
+		attributes := map[string]interface{}{
+			"customer": map[string]interface{}{"cellphone_number": "0818780077"},
+		}
+		err := ApplyPatch([]Operation{
+			{Op: "add", Path: "/customer/name", Value: "Customer"},
+			{Op: "remove", Path: "/customer/cellphone_number"},
+			{Op: "add", Path: "/a~1b", Value: 1.0},
+		}, &attributes)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		customer := attributes["customer"].(map[string]interface{})
+		if customer["name"] != "Customer" || len(customer) != 1 || attributes["a/b"] != 1.0 {
+			t.Fatalf("%s expected attributes patched, got %v", failed, attributes)
+		}
+
+		tags := []string{"a", "c"}
+		err = ApplyPatch([]Operation{
+			{Op: "add", Path: "/1", Value: "b"},
+			{Op: "add", Path: "/-", Value: "d"},
+		}, &tags)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(tags) != 4 || tags[1] != "b" || tags[3] != "d" {
+			t.Fatalf("%s expected tags a b c d, got %v", failed, tags)
+		}
+		t.Logf("%s Result: %v %v", success, attributes, tags)
+	}
+
+	t.Log("Testing a failing JSON patch leaves the output untouched")
+	{
+		target := order()
+		err := ApplyPatch([]Operation{
+			{Op: "replace", Path: "/status", Value: "Order Paid"},
+			{Op: "test", Path: "/order_items/0/price", Value: 30000},
+		}, &target)
+		ferr, ok := err.(*FieldError)
+		if !ok || ferr.Err != ErrTestFailed {
+			t.Fatalf("%s expected a failed test, got %v", failed, err)
+		}
+		if target.Status != "Order Created" {
+			t.Fatalf("%s expected Status untouched, got %s", failed, target.Status)
+		}
+
+		err = ApplyPatch([]Operation{{Op: "remove", Path: "/order_items/5"}}, &target)
+		if ferr, ok := err.(*FieldError); !ok || ferr.Err != ErrPathNotFound || ferr.Path != "/order_items/5" {
+			t.Fatalf("%s expected path not found, got %v", failed, err)
+		}
+		t.Logf("%s Result: %v", success, err)
+	}
+}
+
+func TestApplyPatchOptions(t *testing.T) {
+	t.Log("Testing JSON patch writes to read-only fields of untrusted input are rejected")
+	{
+		target := CustomerEntity{Id: "cus123", Name: "firstex"}
+		ops := []Operation{
+			{Op: "replace", Path: "/Name", Value: "changed"},
+			{Op: "replace", Path: "/Id", Value: "hacked"},
+			{Op: "copy", From: "/Name", Path: "/CreatedBy"},
+		}
+		err := NewMapper(WithUntrustedInput(RejectProtected, "CreatedBy")).ApplyPatch(ops, &target)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != 2 || !hasFieldError(errs, "Id", ErrReadOnly) || !hasFieldError(errs, "CreatedBy", ErrReadOnly) {
+			t.Fatalf("%s expected ErrReadOnly at Id and CreatedBy, got %v", failed, err)
+		}
+		if target.Id != "cus123" || target.Name != "firstex" {
+			t.Fatalf("%s expected no operation applied, got %+v", failed, target)
+		}
+
+		err = NewMapper(WithUntrustedInput(IgnoreProtected)).ApplyPatch([]Operation{
+			{Op: "replace", Path: "/Name", Value: "changed"},
+			{Op: "remove", Path: "/Id"},
+		}, &target)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if target.Id != "cus123" || target.Name != "changed" {
+			t.Fatalf("%s expected only Name replaced, got %+v", failed, target)
+		}
+		t.Logf("%s Result: %+v", success, target)
+	}
+
+	t.Log("Testing JSON patch with a field mask")
+	{
+		target := UserExample{FirstName: "firstex", Authentication: Authentication{Username: "userex", APIToken: "tok"}}
+		ops := []Operation{
+			{Op: "replace", Path: "/FirstName", Value: "changed"},
+			{Op: "replace", Path: "/Authentication", Value: map[string]interface{}{"Username": "changed", "APIToken": "changed"}},
+		}
+		if err := NewMapper(WithFields("Authentication.Username")).ApplyPatch(ops, &target); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if target.FirstName != "firstex" || target.Authentication.Username != "changed" || target.Authentication.APIToken != "tok" {
+			t.Fatalf("%s expected only Authentication.Username replaced, got %+v", failed, target)
+		}
+		t.Logf("%s Result: %+v", success, target)
+	}
+
+	t.Log("Testing JSON patch with a profile")
+	{
+		target := AccountEntity{Id: "acc123", APIToken: "token"}
+		if err := NewMapper(WithProfile("admin")).ApplyPatch([]Operation{{Op: "replace", Path: "/api_token", Value: "changed"}}, &target); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if target.APIToken != "changed" {
+			t.Fatalf("%s expected APIToken = changed, got %+v", failed, target)
+		}
+
+		err := NewMapper(WithProfile("public")).ApplyPatch([]Operation{{Op: "replace", Path: "/APIToken", Value: "hacked"}}, &target)
+		if fieldErr, ok := err.(*FieldError); !ok || fieldErr.Err != ErrPathNotFound || target.APIToken != "changed" {
+			t.Fatalf("%s expected ErrPathNotFound for a field excluded from the profile, got %v", failed, err)
+		}
+		t.Logf("%s Result: %+v", success, target)
+	}
+}
+
+func TestApplyPatchReadOnlyWalk(t *testing.T) {
+	t.Log("Testing test and copy operations do not allocate nil pointers")
+	{
+		snapshot := AggregateSnapshot{}
+		testCases := []Operation{
+			{Op: "test", Path: "/Owner/FirstName", Value: ""},
+			{Op: "copy", From: "/Owner/FirstName", Path: "/Payload"},
+			{Op: "remove", Path: "/Owner/FirstName"},
+		}
+		for _, op := range testCases {
+			err := ApplyPatch([]Operation{op}, &snapshot)
+			if fieldErr, ok := err.(*FieldError); !ok || fieldErr.Err != ErrPathNotFound {
+				t.Fatalf("%s expected ErrPathNotFound for %s, got %v", failed, op.Op, err)
+			}
+		}
+		if snapshot.Owner != nil {
+			t.Fatalf("%s expected Owner nil, got %+v", failed, snapshot.Owner)
+		}
+		t.Logf("%s Result: %+v", success, snapshot)
+	}
+
+	t.Log("Testing a move into its own child is rejected")
+	{
+		snapshot := AggregateSnapshot{Items: []OrderItem{{Id: "itm123"}}}
+		err := ApplyPatch([]Operation{{Op: "move", From: "/Items", Path: "/Items/0"}}, &snapshot)
+		if fieldErr, ok := err.(*FieldError); !ok || fieldErr.Err != ErrInvalidOp {
+			t.Fatalf("%s expected ErrInvalidOp, got %v", failed, err)
+		}
+		t.Logf("%s Result: %s", success, err.Error())
+	}
+}
@@ -0,0 +1,149 @@
+package teepr
+
+import (
+	"reflect"
+)
+
+// Mapper maps input values into output values following a set of options
+type Mapper struct {
+	customValues []func(interface{}) (interface{}, error)
+	strictKeys   bool
+	strictFields bool
+	merge        MergeMode
+	precedence   Precedence
+	shareRefs    bool
+	mergePatch   bool
+	fields       [][]string
+	redaction    Redaction
+	profile      string
+	untrusted    bool
+	protection   ProtectionPolicy
+	protected    [][]string
+	writable     [][]string
+
+	defaultOnZero bool
+	required      RequiredPolicy
+
+	before map[hookKey][]HookFunc
+	after  map[hookKey][]HookFunc
+
+	transforms map[string]TransformFunc
+
+	collection    CollectionStrategy
+	collectionKey string
+}
+
+// Option configures a Mapper
+type Option func(m *Mapper)
+
+// NewMapper creates a Mapper configured by opts
+func NewMapper(opts ...Option) *Mapper {
+	m := &Mapper{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WithCustomValues adds functions converting values the built-in rules do not handle,
+// they are tried in order
+func WithCustomValues(customValues ...func(interface{}) (interface{}, error)) Option {
+	return func(m *Mapper) {
+		m.customValues = append(m.customValues, customValues...)
+	}
+}
+
+// WithStrictKeys makes the mapping fail when a key of an input map is consumed by no output field
+func WithStrictKeys() Option {
+	return func(m *Mapper) {
+		m.strictKeys = true
+	}
+}
+
+// WithStrictFields makes the mapping fail when a field of an output struct receives no input value
+func WithStrictFields() Option {
+	return func(m *Mapper) {
+		m.strictFields = true
+	}
+}
+
+// Teepr maps the value of input into output, output must be a pointer
+func (m *Mapper) Teepr(input interface{}, output interface{}) error {
+	_, err := m.TeeprFields(input, output)
+	return err
+}
+
+// iteration holds the state of a single Mapper.Teepr call
+type iteration struct {
+	*Mapper
+	errs    Errors
+	stack   map[visit]bool
+	shared  map[sharedKey]reflect.Value
+	present FieldSet
+
+	finish  finishMode
+	sources map[string]interface{}
+	hooked  map[string]bool
+
+	collection    CollectionStrategy
+	collectionKey string
+}
+
+// finishMode tells when the steps run once the fields of an output struct are mapped
+type finishMode int
+
+const (
+	// finishNow runs the steps on each struct as soon as it is mapped
+	finishNow finishMode = iota
+	// finishLater records the mapped structs, finishAll runs the steps once on the final output
+	finishLater
+	// finishNever skips the steps and the hooks
+	finishNever
+)
+
+// finishStruct runs the steps following the mapping of the fields of the struct oval from src:
+// transforms, required fields, defaults, after hooks, validation and presence
+func (it *iteration) finishStruct(src interface{}, oval reflect.Value, path string, matched map[string]bool) {
+	switch it.finish {
+	case finishLater:
+		if it.sources == nil {
+			it.sources = make(map[string]interface{})
+		}
+		it.sources[path] = src
+		return
+	case finishNever:
+		return
+	}
+
+	it.transform(oval, path)
+	it.checkRequired(oval, path)
+	it.applyDefaults(oval, path, matched)
+	it.afterHooks(src, oval, path)
+	it.validate(oval, path)
+	it.validateFields(oval, path)
+	it.callValidate(oval, path)
+	it.checkUnsetFields(oval.Type(), path, matched)
+	it.fillPresence(oval, path)
+}
+
+func (it *iteration) addError(path string, err error) {
+	it.errs = append(it.errs, &FieldError{Path: path, Err: err})
+}
+
+// checkUnsetFields reports, in strict fields mode, every field of the struct type
+// that is not in matched
+func (it *iteration) checkUnsetFields(typ reflect.Type, path string, matched map[string]bool) {
+	if !it.strictFields {
+		return
+	}
+
+	fields := it.profileFields(typ)
+	remain, hasRemain := remainField(fields)
+	for _, f := range fields {
+		fpath := joinPath(path, f.Name)
+		if matched[f.Name] || hasRemain && f.Name == remain.Name || !it.inMask(fpath) || it.isProtected(f.StructField, fpath) {
+			continue
+		}
+		it.addError(fpath, ErrUnsetField)
+	}
+}
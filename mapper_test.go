@@ -0,0 +1,87 @@
+package teepr
+
+import (
+	"testing"
+)
+
+func TestMapperStrict(t *testing.T) {
+	t.Log("Testing strict keys with unknown keys in a nested map")
+	{
+		input := map[string]interface{}{
+			"FirstName": "firstex",
+			"Nickname":  "fx",
+			"Authentication": map[string]interface{}{
+				"Username": "xiexample",
+				"Password": "secret",
+			},
+		}
+
+		output := UserExample{}
+		err := NewMapper(WithStrictKeys()).Teepr(input, &output)
+		errs, ok := err.(Errors)
+		if !ok {
+			t.Fatalf("%s expected error of type Errors, got %v", failed, err)
+		}
+		if len(errs) != 2 || !hasFieldError(errs, "Nickname", ErrUnknownKey) || !hasFieldError(errs, "Authentication.Password", ErrUnknownKey) {
+			t.Fatalf("%s expected unknown keys Nickname and Authentication.Password, got %v", failed, errs)
+		}
+		if output.FirstName != "firstex" || output.Authentication.Username != "xiexample" {
+			t.Fatalf("%s expected known keys are still mapped, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %v", success, err)
+
+		err = Teepr(input, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil without strict keys, got %s", failed, err.Error())
+		}
+	}
+
+	t.Log("Testing strict fields with unmapped fields")
+	{
+		input := struct {
+			FirstName string
+			LastName  string
+		}{"firstex", "lastex"}
+
+		output := ProfileExample{}
+		err := NewMapper(WithStrictFields()).Teepr(input, &output)
+		errs, ok := err.(Errors)
+		if !ok {
+			t.Fatalf("%s expected error of type Errors, got %v", failed, err)
+		}
+		for _, path := range []string{"Email", "Title", "Authentication"} {
+			if !hasFieldError(errs, path, ErrUnsetField) {
+				t.Fatalf("%s expected unset field %s, got %v", failed, path, errs)
+			}
+		}
+		if len(errs) != 3 {
+			t.Fatalf("%s expected 3 unset fields, got %v", failed, errs)
+		}
+		t.Logf("%s Result: %v", success, err)
+	}
+
+	t.Log("Testing strict keys and fields with a remain field")
+	{
+		input := map[string]interface{}{
+			"id":      "000000010",
+			"status":  "Order Created",
+			"channel": "mobile",
+		}
+
+		output := OrderCreatedPayload{}
+		err := NewMapper(WithStrictKeys(), WithStrictFields()).Teepr(input, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+}
+
+func hasFieldError(errs Errors, path string, target error) bool {
+	for _, e := range errs {
+		if e.Path == path && e.Err == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package teepr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MaskWildcard matches any single segment of a field mask path, e.g. "Items.*.Price"
+const MaskWildcard = "*"
+
+// WithFields restricts the mapping to the listed dotted paths and everything below them,
+// paths name output struct fields or output map keys and may use MaskWildcard for slice
+// indexes and map keys. Output values outside of the mask are left untouched
+func WithFields(paths ...string) Option {
+	return func(m *Mapper) {
+		for _, p := range paths {
+			m.fields = append(m.fields, strings.Split(p, FlattenSeparator))
+		}
+	}
+}
+
+// inMask reports whether the value at path is either covered by a mask path
+// or on the way to one
+func (it *iteration) inMask(path string) bool {
+	if len(it.fields) == 0 || path == "" {
+		return true
+	}
+
+	segments := strings.Split(path, FlattenSeparator)
+	for _, mask := range it.fields {
+		if matchSegments(mask, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// partlyMasked reports whether only some of the values below path are in the mask,
+// such values are mapped onto the existing output to leave the rest untouched
+func (it *iteration) partlyMasked(path string) bool {
+	if len(it.fields) == 0 {
+		return false
+	}
+
+	segments := strings.Split(path, FlattenSeparator)
+	for _, mask := range it.fields {
+		if len(mask) <= len(segments) && matchSegments(mask, segments) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSegments reports whether the segments of a path and of a mask path
+// are equal up to the shorter of the two
+func matchSegments(mask, segments []string) bool {
+	n := len(mask)
+	if len(segments) < n {
+		n = len(segments)
+	}
+	for i := 0; i < n; i++ {
+		if mask[i] != MaskWildcard && mask[i] != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneMask drops from a generic value of toMapValue the entries outside of the mask
+func (it *iteration) pruneMask(v interface{}, path string) interface{} {
+	if len(it.fields) == 0 {
+		return v
+	}
+
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		for k, e := range tv {
+			p := joinPath(path, k)
+			if !it.inMask(p) {
+				delete(tv, k)
+				continue
+			}
+			tv[k] = it.pruneMask(e, p)
+		}
+	case []interface{}:
+		for i, e := range tv {
+			tv[i] = it.pruneMask(e, joinPath(path, strconv.Itoa(i)))
+		}
+	}
+	return v
+}
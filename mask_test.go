@@ -0,0 +1,94 @@
+package teepr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldMask(t *testing.T) {
+	t.Log("Testing WithFields copies only the masked fields into a struct")
+	{
+		input := UserExample{
+			FirstName: "firstex",
+			LastName:  "lastex",
+			Authentication: Authentication{
+				Username:  "userex",
+				APISecret: "secret",
+			},
+		}
+		output := UserExample{
+			LastName: "kept",
+			Authentication: Authentication{
+				APISecret: "keep",
+				APIToken:  "tok",
+			},
+		}
+		if err := NewMapper(WithFields("FirstName", "Authentication.Username")).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.FirstName != "firstex" || output.Authentication.Username != "userex" {
+			t.Fatalf("%s expected masked fields copied, got %+v", failed, output)
+		}
+		if output.LastName != "kept" || output.Authentication.APISecret != "keep" || output.Authentication.APIToken != "tok" {
+			t.Fatalf("%s expected fields outside of the mask untouched, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing WithFields with a wildcard into a sparse map")
+	{
+		input := OrderEx{
+			Id:     "ord123",
+			Status: "created",
+			Items: []OrderItem{
+				{Id: "itm1", ItemName: "XL 2 Giga", Price: 150000},
+				{Id: "itm2", ItemName: "XL 5 Giga", Price: 250000},
+			},
+		}
+		output := make(map[string]interface{})
+		if err := NewMapper(WithFields("Id", "Items.*.Price")).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		expected := map[string]interface{}{
+			"Id": "ord123",
+			"Items": []interface{}{
+				map[string]interface{}{"Price": float64(150000)},
+				map[string]interface{}{"Price": float64(250000)},
+			},
+		}
+		if !reflect.DeepEqual(output, expected) {
+			t.Fatalf("%s expected %v, got %v", failed, expected, output)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing WithFields with a wildcard onto an existing slice")
+	{
+		input := OrderEx{Items: []OrderItem{{Id: "changed", Price: 175000}}}
+		output := OrderEx{Id: "ord123", Items: []OrderItem{{Id: "itm1", ItemName: "XL 2 Giga", Price: 150000}}}
+		if err := NewMapper(WithFields("Items.*.Price")).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		expected := []OrderItem{{Id: "itm1", ItemName: "XL 2 Giga", Price: 175000}}
+		if output.Id != "ord123" || !reflect.DeepEqual(output.Items, expected) {
+			t.Fatalf("%s expected only the prices updated, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing WithFields on a partial update from a map")
+	{
+		input := map[string]interface{}{
+			"Status": "paid",
+			"Id":     "changed",
+		}
+		output := OrderEx{Id: "ord123", Status: "created"}
+		if err := NewMapper(WithFields("Status"), WithStrictFields()).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Status != "paid" || output.Id != "ord123" {
+			t.Fatalf("%s expected only Status updated, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+}
@@ -0,0 +1,374 @@
+package teepr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MergeMode decides which source values are written into the output
+type MergeMode int
+
+const (
+	// MergeNone writes every matched source value, this is the default
+	MergeNone MergeMode = iota
+	// MergeZero leaves the output untouched for zero and nil source values
+	MergeZero
+	// MergeEmpty leaves the output untouched for source values IsEmpty reports as empty
+	MergeEmpty
+)
+
+// WithMerge sets the merge mode, so a partially filled input can be mapped onto an existing output.
+// A field tagged `teepr:",always"` is always written, a field tagged `teepr:",omitempty"`
+// is skipped when zero even without a merge mode
+func WithMerge(mode MergeMode) Option {
+	return func(m *Mapper) {
+		m.merge = mode
+	}
+}
+
+// skipValue reports whether a source value must leave the output untouched,
+// fields are the source and output fields whose tags may override the merge mode
+func (it *iteration) skipValue(val reflect.Value, fields ...reflect.StructField) bool {
+	mode := it.merge
+	for _, f := range fields {
+		if hasTagOption(f, "always") {
+			return false
+		}
+		if mode == MergeNone && hasTagOption(f, "omitempty") {
+			mode = MergeZero
+		}
+	}
+
+	switch mode {
+	case MergeZero:
+		return !val.IsValid() || val.IsZero()
+	case MergeEmpty:
+		return !val.IsValid() || IsEmpty(val.Interface())
+	}
+	return false
+}
+
+// Precedence decides which source of Merge wins when several give a value to the same field
+type Precedence int
+
+const (
+	// LastWins keeps the value of the last source, this is the default
+	LastWins Precedence = iota
+	// FirstWins keeps the value of the first source
+	FirstWins
+)
+
+// WithPrecedence sets the precedence used by Merge
+func WithPrecedence(p Precedence) Option {
+	return func(m *Mapper) {
+		m.precedence = p
+	}
+}
+
+// Conflict describes a path that two sources of Merge give different values,
+// Old comes from an earlier source and New from the source at index Source
+type Conflict struct {
+	Path   string
+	Old    interface{}
+	New    interface{}
+	Source int
+}
+
+// Merge maps the sources in order onto out, zero source values never overwrite
+// values given by other sources. It returns every conflict found between the sources
+func Merge(out interface{}, sources ...interface{}) ([]Conflict, error) {
+	return NewMapper().Merge(out, sources...)
+}
+
+// Merge maps the sources in order onto out following the precedence of the Mapper,
+// MergeZero is used when no merge mode is set. Hooks, defaults and checks run once
+// on the merged output
+func (m *Mapper) Merge(out interface{}, sources ...interface{}) ([]Conflict, error) {
+	mm := *m
+	if mm.merge == MergeNone {
+		mm.merge = MergeZero
+	}
+
+	otyp := reflect.Indirect(reflect.ValueOf(out)).Type()
+	var conflicts []Conflict
+	values := make(map[string]interface{})
+	for i, source := range sources {
+		tmp := reflect.New(otyp)
+		if otyp.Kind() == reflect.Map {
+			tmp.Elem().Set(reflect.MakeMap(otyp))
+		}
+		it := &iteration{Mapper: &mm, collection: mm.collection, collectionKey: mm.collectionKey, finish: finishNever}
+		if err := it.teepr(source, tmp.Interface(), ""); err != nil {
+			return conflicts, err
+		}
+		if len(it.errs) > 0 {
+			return conflicts, it.errs
+		}
+
+		f := newFlattener()
+		f.flatten(tmp, "")
+		backRefs := make(map[string]bool, len(f.cycles))
+		for _, path := range f.cycles {
+			backRefs[path] = true
+		}
+		for path, v := range f.result {
+			if v == nil || reflect.ValueOf(v).IsZero() || backRefs[path] {
+				continue
+			}
+			if old, found := values[path]; found && !reflect.DeepEqual(old, v) {
+				conflicts = append(conflicts, Conflict{Path: path, Old: old, New: v, Source: i})
+			}
+			if _, found := values[path]; !found || mm.precedence == LastWins {
+				values[path] = v
+			}
+		}
+	}
+
+	it := &iteration{Mapper: &mm, finish: finishLater}
+	for i := range sources {
+		source := sources[i]
+		if mm.precedence == FirstWins {
+			source = sources[len(sources)-1-i]
+		}
+		it.collection, it.collectionKey = mm.collection, mm.collectionKey
+		if err := it.teepr(source, out, ""); err != nil {
+			return conflicts, err
+		}
+	}
+	it.finishAll(reflect.ValueOf(out), "", make(map[visit]bool))
+	it.validateValue(reflect.ValueOf(out), "")
+	if len(it.errs) > 0 {
+		return conflicts, it.errs
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Source != conflicts[j].Source {
+			return conflicts[i].Source < conflicts[j].Source
+		}
+		return conflicts[i].Path < conflicts[j].Path
+	})
+	return conflicts, nil
+}
+
+// finishAll runs the steps of finishStruct once on every struct of the final output
+// mapped from a source, nested values first. A struct field is considered matched
+// when any source gave it a value
+func (it *iteration) finishAll(val reflect.Value, path string, seen map[visit]bool) {
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			return
+		}
+		key := visit{val.Pointer(), val.Type(), 0}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		it.finishAll(val.Elem(), path, seen)
+	case reflect.Struct:
+		if isLeafStruct(val.Type()) {
+			return
+		}
+		fields := it.profileFields(val.Type())
+		for _, f := range fields {
+			if fval, ok := fieldByIndex(val, f.index, false); ok {
+				it.finishAll(fval, joinPath(path, f.Name), seen)
+			}
+		}
+
+		src, found := it.sources[path]
+		if !found || !val.CanAddr() {
+			return
+		}
+		matched := make(map[string]bool)
+		for _, f := range fields {
+			matched[f.Name] = it.present.Has(joinPath(path, f.Name))
+		}
+		it.finish = finishNow
+		it.finishStruct(src, val, path, matched)
+		it.finish = finishLater
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			it.finishAll(val.Index(i), joinPath(path, strconv.Itoa(i)), seen)
+		}
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, k := range val.MapKeys() {
+			elem := reflect.New(val.Type().Elem()).Elem()
+			elem.Set(val.MapIndex(k))
+			it.finishAll(elem, joinPath(path, k.String()), seen)
+			val.SetMapIndex(k, elem)
+		}
+	}
+}
+
+// CollectionStrategy decides how input slices and maps are mapped onto existing output collections
+type CollectionStrategy int
+
+const (
+	// Replace builds a new output slice, this is the default
+	Replace CollectionStrategy = iota
+	// Append appends the input elements to the output slice
+	Append
+	// MergeByIndex maps every input element onto the output element at the same index,
+	// or for maps at the same key
+	MergeByIndex
+	// MergeByKey maps every input element onto the output element having the same key field value,
+	// e.g. OrderItems matched by ItemID. Unmatched input elements are appended
+	MergeByKey
+)
+
+// WithCollectionStrategy sets the strategy used for slice and map outputs, key is the field used by MergeByKey.
+// A field tagged `teepr:",collection=append"`, `teepr:",collection=index"`, `teepr:",collection=replace"`
+// or `teepr:",collection=key:ItemID"` overrides it
+func WithCollectionStrategy(strategy CollectionStrategy, key ...string) Option {
+	return func(m *Mapper) {
+		m.collection = strategy
+		if len(key) > 0 {
+			m.collectionKey = key[0]
+		}
+	}
+}
+
+// useCollection sets the collection strategy of the value about to be mapped into field
+func (it *iteration) useCollection(field reflect.StructField) {
+	it.collection, it.collectionKey = it.Mapper.collection, it.Mapper.collectionKey
+
+	v, ok := tagValue(field, "collection")
+	if !ok {
+		return
+	}
+	switch {
+	case v == "replace":
+		it.collection = Replace
+	case v == "append":
+		it.collection = Append
+	case v == "index":
+		it.collection = MergeByIndex
+	case strings.HasPrefix(v, "key:"):
+		it.collection, it.collectionKey = MergeByKey, strings.TrimPrefix(v, "key:")
+	}
+}
+
+// mapSlice maps the elements of the slice ival into the slice oval following the collection strategy
+func (it *iteration) mapSlice(ival, oval reflect.Value, path string) error {
+	strategy, key := it.collection, it.collectionKey
+	if strategy == Replace && (it.partlyMasked(path) || it.partlyWritable(path)) {
+		strategy = MergeByIndex
+	}
+	otyp := oval.Type()
+
+	var outSlice reflect.Value
+	switch strategy {
+	case Append:
+		outSlice = oval
+	case MergeByIndex, MergeByKey:
+		outSlice = reflect.MakeSlice(otyp, oval.Len(), oval.Len()+ival.Len())
+		reflect.Copy(outSlice, oval)
+	default:
+		outSlice = reflect.MakeSlice(reflect.SliceOf(otyp.Elem()), 0, ival.Len())
+	}
+
+	var keys map[string]int
+	if strategy == MergeByKey {
+		keys = make(map[string]int)
+		for i := 0; i < outSlice.Len(); i++ {
+			if k, ok := elementKey(outSlice.Index(i), key, nil); ok {
+				keys[k] = i
+			}
+		}
+	}
+
+	for i := 0; i < ival.Len(); i++ {
+		iItem := ival.Index(i)
+		if iItem.Kind() == reflect.Interface {
+			iItem = iItem.Elem()
+		}
+
+		idx := -1
+		switch strategy {
+		case MergeByIndex:
+			if i < outSlice.Len() {
+				idx = i
+			}
+		case MergeByKey:
+			if k, ok := elementKey(iItem, key, keyTagNames(otyp.Elem(), key)); ok {
+				if found, ok := keys[k]; ok {
+					idx = found
+				}
+			}
+		}
+
+		oItem := reflect.New(otyp.Elem())
+		ipath := joinPath(path, strconv.Itoa(outSlice.Len()))
+		if idx >= 0 {
+			oItem.Elem().Set(outSlice.Index(idx))
+			ipath = joinPath(path, strconv.Itoa(idx))
+		}
+		if ref, found := it.sharedRef(iItem, otyp.Elem()); found {
+			oItem.Elem().Set(ref)
+		} else if iItem.IsValid() {
+			if err := it.teepr(iItem.Interface(), oItem.Interface(), ipath); err != nil {
+				return err
+			}
+		}
+
+		if idx >= 0 {
+			outSlice.Index(idx).Set(oItem.Elem())
+		} else {
+			outSlice = reflect.Append(outSlice, oItem.Elem())
+		}
+	}
+
+	oval.Set(outSlice)
+	return nil
+}
+
+// elementKey gives the value of the key field of a slice element as a string,
+// map elements are looked up by the key and then by names
+func elementKey(val reflect.Value, key string, names []string) (string, bool) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return "", false
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		if f, found := findField(structFields(val.Type()), key); found {
+			if fval, ok := fieldByIndex(val, f.index, false); ok {
+				return fmt.Sprint(fval.Interface()), true
+			}
+		}
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			break
+		}
+		for _, name := range append([]string{key}, names...) {
+			if mval := val.MapIndex(reflect.ValueOf(name).Convert(val.Type().Key())); mval.IsValid() {
+				return fmt.Sprint(mval.Interface()), true
+			}
+		}
+	}
+	return "", false
+}
+
+// keyTagNames gives the tag names of the key field of a struct type
+func keyTagNames(typ reflect.Type, key string) []string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	if f, found := findField(structFields(typ), key); found {
+		return tagNames(f.StructField)
+	}
+	return nil
+}
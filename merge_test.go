@@ -0,0 +1,369 @@
+package teepr
+
+import (
+	"reflect"
+	"testing"
+)
+
+type PatchUserRequest struct {
+	FirstName      string
+	LastName       string
+	Email          *string
+	Authentication *Authentication
+}
+
+type UserSettings struct {
+	Title   string
+	Active  bool `teepr:",always"`
+	Notes   interface{}
+	Counter int `teepr:",omitempty"`
+}
+
+func TestMergeMode(t *testing.T) {
+	t.Log("Testing merge mode with zero source fields")
+	{
+		entity := UserExample{
+			FirstName: "firstex",
+			LastName:  "lastex",
+			Email:     "firstduo@example.com",
+			Authentication: Authentication{
+				Username:  "xiexample",
+				APISecret: "apisecret",
+			},
+		}
+
+		request := PatchUserRequest{
+			LastName:       "newlast",
+			Authentication: &Authentication{APIToken: "newtoken"},
+		}
+
+		err := NewMapper(WithMerge(MergeZero)).Teepr(request, &entity)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if entity.FirstName != "firstex" || entity.LastName != "newlast" || entity.Email != "firstduo@example.com" {
+			t.Fatalf("%s expected only LastName updated, got %+v", failed, entity)
+		}
+		if entity.Authentication.Username != "xiexample" || entity.Authentication.APISecret != "apisecret" || entity.Authentication.APIToken != "newtoken" {
+			t.Fatalf("%s expected only Authentication.APIToken updated, got %+v", failed, entity.Authentication)
+		}
+		t.Logf("%s Result: %+v", success, entity)
+	}
+
+	t.Log("Testing merge mode with map input")
+	{
+		entity := UserExample{FirstName: "firstex", LastName: "lastex"}
+		input := map[string]interface{}{"FirstName": nil, "LastName": "newlast"}
+
+		err := NewMapper(WithMerge(MergeZero)).Teepr(input, &entity)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if entity.FirstName != "firstex" || entity.LastName != "newlast" {
+			t.Fatalf("%s expected only LastName updated, got %+v", failed, entity)
+		}
+		t.Logf("%s Result: %+v", success, entity)
+	}
+
+	t.Log("Testing merge modes with tag overrides")
+	{
+		settings := UserSettings{Title: "title", Active: true, Notes: "note", Counter: 3}
+		input := struct {
+			Title   string
+			Active  bool
+			Notes   interface{}
+			Counter int
+		}{Notes: 0}
+
+		err := NewMapper(WithMerge(MergeZero)).Teepr(input, &settings)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if settings.Title != "title" || settings.Active || settings.Notes != 0 {
+			t.Fatalf("%s expected Title kept, Active cleared and Notes = 0, got %+v", failed, settings)
+		}
+
+		settings = UserSettings{Title: "title", Notes: "note", Counter: 3}
+		err = NewMapper(WithMerge(MergeEmpty)).Teepr(input, &settings)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if settings.Notes != "note" {
+			t.Fatalf("%s expected Notes kept with MergeEmpty, got %+v", failed, settings)
+		}
+
+		err = Teepr(input, &settings)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if settings.Title != "" || settings.Counter != 3 {
+			t.Fatalf("%s expected Title overwritten and Counter kept without merge mode, got %+v", failed, settings)
+		}
+		t.Logf("%s Result: %+v", success, settings)
+	}
+}
+
+func TestMergeSources(t *testing.T) {
+	userRow := struct {
+		FirstName string
+		LastName  string
+		Email     string
+	}{"firstex", "lastex", "first@example.com"}
+
+	profileRow := ProfileExample{
+		Title: "title",
+		Email: "profile@example.com",
+	}
+
+	authSettings := map[string]interface{}{
+		"Authentication": map[string]interface{}{
+			"Username": "xiexample",
+			"APIToken": "apitoken",
+		},
+	}
+
+	t.Log("Testing Merge with last wins precedence")
+	{
+		output := UserExample{}
+		conflicts, err := Merge(&output, userRow, profileRow, authSettings)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		if output.FirstName != "firstex" || output.Title != "title" || output.Authentication.Username != "xiexample" {
+			t.Fatalf("%s expected values of every source merged, got %+v", failed, output)
+		}
+		if output.Email != "profile@example.com" {
+			t.Fatalf("%s expected Email = profile@example.com, got %s", failed, output.Email)
+		}
+		if len(conflicts) != 1 || conflicts[0].Path != "Email" || conflicts[0].Old != "first@example.com" || conflicts[0].New != "profile@example.com" || conflicts[0].Source != 1 {
+			t.Fatalf("%s expected a conflict on Email, got %+v", failed, conflicts)
+		}
+		t.Logf("%s Result: %+v, conflicts: %+v", success, output, conflicts)
+	}
+
+	t.Log("Testing Merge with first wins precedence")
+	{
+		output := UserExample{}
+		conflicts, err := NewMapper(WithPrecedence(FirstWins)).Merge(&output, userRow, profileRow, authSettings)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		if output.Email != "first@example.com" || output.LastName != "lastex" || output.Authentication.APIToken != "apitoken" {
+			t.Fatalf("%s expected Email = first@example.com with every source merged, got %+v", failed, output)
+		}
+		if len(conflicts) != 1 || conflicts[0].Old != "first@example.com" {
+			t.Fatalf("%s expected a conflict on Email, got %+v", failed, conflicts)
+		}
+		t.Logf("%s Result: %+v, conflicts: %+v", success, output, conflicts)
+	}
+}
+
+type OrderItemsUpdate struct {
+	Id    string
+	Items []OrderItem `teepr:",collection=append"`
+}
+
+func TestCollectionStrategy(t *testing.T) {
+	existing := func() OrderEx {
+		return OrderEx{
+			Id: "o123",
+			Items: []OrderItem{
+				{Id: "itm123", ItemName: "XL 2 Giga", Price: 150000},
+				{Id: "itm124", ItemName: "XL 5 Giga", Price: 300000},
+			},
+		}
+	}
+	input := struct {
+		Items []struct {
+			Id    string
+			Price float64
+		}
+	}{}
+	input.Items = append(input.Items, struct {
+		Id    string
+		Price float64
+	}{"itm124", 350000})
+
+	t.Log("Testing Append strategy")
+	{
+		order := existing()
+		err := NewMapper(WithCollectionStrategy(Append)).Teepr(input, &order)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(order.Items) != 3 || order.Items[2].Price != 350000 || order.Items[0].ItemName != "XL 2 Giga" {
+			t.Fatalf("%s expected 3 items with the input item appended, got %+v", failed, order.Items)
+		}
+		t.Logf("%s Result: %+v", success, order.Items)
+	}
+
+	t.Log("Testing MergeByIndex strategy")
+	{
+		order := existing()
+		err := NewMapper(WithCollectionStrategy(MergeByIndex)).Teepr(input, &order)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(order.Items) != 2 || order.Items[0].Id != "itm124" || order.Items[0].ItemName != "XL 2 Giga" || order.Items[0].Price != 350000 {
+			t.Fatalf("%s expected the first item merged with the input item, got %+v", failed, order.Items)
+		}
+		t.Logf("%s Result: %+v", success, order.Items)
+	}
+
+	t.Log("Testing MergeByKey strategy")
+	{
+		order := existing()
+		err := NewMapper(WithCollectionStrategy(MergeByKey, "Id")).Teepr(input, &order)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(order.Items) != 2 || order.Items[1].ItemName != "XL 5 Giga" || order.Items[1].Price != 350000 {
+			t.Fatalf("%s expected item itm124 merged with the input item, got %+v", failed, order.Items)
+		}
+		t.Logf("%s Result: %+v", success, order.Items)
+	}
+
+	t.Log("Testing MergeByKey strategy with map input")
+	{
+		order := Order{
+			ID: "000000010",
+			OrderItems: []OrderItemOp{
+				{ItemID: 15540, Name: "XL 5 giga", Price: 25000},
+				{ItemID: 15541, Name: "Telkomsel flash 5 giga", Price: 25000},
+			},
+		}
+		input := map[string]interface{}{
+			"order_items": []interface{}{
+				map[string]interface{}{"item_id": float64(15541), "price": float64(30000)},
+				map[string]interface{}{"item_id": float64(15542), "name": "Indosat 1 giga"},
+			},
+		}
+
+		err := NewMapper(WithCollectionStrategy(MergeByKey, "ItemID")).Teepr(input, &order)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(order.OrderItems) != 3 {
+			t.Fatalf("%s expected 3 order items, got %+v", failed, order.OrderItems)
+		}
+		if item := order.OrderItems[1]; item.Name != "Telkomsel flash 5 giga" || item.Price != 30000 {
+			t.Fatalf("%s expected item 15541 merged with the input item, got %+v", failed, item)
+		}
+		if item := order.OrderItems[2]; item.ItemID != 15542 || item.Name != "Indosat 1 giga" {
+			t.Fatalf("%s expected item 15542 appended, got %+v", failed, item)
+		}
+		t.Logf("%s Result: %+v", success, order.OrderItems)
+	}
+
+	t.Log("Testing collection strategy tag")
+	{
+		update := OrderItemsUpdate{Id: "o123", Items: existing().Items}
+		err := Teepr(input, &update)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(update.Items) != 3 {
+			t.Fatalf("%s expected 3 items with the input item appended, got %+v", failed, update.Items)
+		}
+		t.Logf("%s Result: %+v", success, update.Items)
+	}
+
+	t.Log("Testing MergeByIndex strategy on map elements")
+	{
+		services := map[string]ServiceDetail{
+			"1": {"Premium MS Order", 15000},
+		}
+		input := map[string]map[string]interface{}{
+			"1": {"Cost": float64(20000)},
+		}
+
+		err := NewMapper(WithCollectionStrategy(MergeByIndex)).Teepr(input, &services)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if services["1"].Service != "Premium MS Order" || services["1"].Cost != 20000 {
+			t.Fatalf("%s expected map element merged with the input element, got %+v", failed, services)
+		}
+		t.Logf("%s Result: %+v", success, services)
+	}
+}
+
+type MergedContact struct {
+	FirstName string `teepr:",required"`
+	LastName  string `teepr:",required"`
+	Status    string `teepr:",default=ACTIVE"`
+	hooks     []string
+}
+
+func (c *MergedContact) BeforeTeepr(src interface{}) error {
+	c.hooks = append(c.hooks, "before")
+	return nil
+}
+
+func (c *MergedContact) AfterTeepr(src interface{}) error {
+	c.hooks = append(c.hooks, "after")
+	return nil
+}
+
+func TestMergeChecks(t *testing.T) {
+	t.Log("Testing Merge runs hooks, defaults and checks once on the merged output")
+	{
+		var output MergedContact
+		conflicts, err := NewMapper(WithStrictFields()).Merge(&output,
+			map[string]interface{}{"FirstName": "firstex"},
+			map[string]interface{}{"LastName": "lastex", "Status": "INVITED"},
+		)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(conflicts) != 0 {
+			t.Fatalf("%s expected no conflict, got %+v", failed, conflicts)
+		}
+		if output.FirstName != "firstex" || output.LastName != "lastex" || output.Status != "INVITED" {
+			t.Fatalf("%s expected the merged contact, got %+v", failed, output)
+		}
+		if !reflect.DeepEqual(output.hooks, []string{"before", "after"}) {
+			t.Fatalf("%s expected each hook called once, got %v", failed, output.hooks)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing Merge reports required fields no source gives a value")
+	{
+		var output MergedContact
+		_, err := Merge(&output, map[string]interface{}{"FirstName": "firstex"}, map[string]interface{}{})
+		errs, _ := err.(Errors)
+		if len(errs) != 1 || !hasFieldError(errs, "LastName", ErrRequired) {
+			t.Fatalf("%s expected only LastName reported missing, got %v", failed, err)
+		}
+		if output.Status != "ACTIVE" {
+			t.Fatalf("%s expected Status = ACTIVE, got %s", failed, output.Status)
+		}
+		t.Logf("%s Result: %s", success, err.Error())
+	}
+}
+
+func TestMergeSharedReferences(t *testing.T) {
+	t.Log("Testing Merge self-referencing sources with shared references")
+	{
+		first := &MenuNode{Name: "root"}
+		first.Children = []*MenuNode{{Name: "child", Parent: first}}
+		second := &MenuNode{Name: "menu"}
+		second.Children = []*MenuNode{{Name: "child", Parent: second}}
+
+		var output MenuNodeView
+		conflicts, err := NewMapper(WithSharedReferences()).Merge(&output, first, second)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if len(conflicts) != 1 || conflicts[0].Path != "Name" {
+			t.Fatalf("%s expected only a conflict at Name, got %+v", failed, conflicts)
+		}
+		if output.Name != "menu" || len(output.Children) != 1 || output.Children[0].Parent != &output {
+			t.Fatalf("%s expected the merged menu with the child parent at the output root, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, conflicts)
+	}
+}
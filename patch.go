@@ -0,0 +1,41 @@
+package teepr
+
+import (
+	"reflect"
+)
+
+// ApplyMergePatch applies a JSON merge patch (RFC 7386) decoded into a map onto out.
+// A null value zeroes the field, or removes the key of a map, objects are applied recursively
+// and other values are converted with the rules of Teepr
+func ApplyMergePatch(patch map[string]interface{}, out interface{}) error {
+	return NewMapper().ApplyMergePatch(patch, out)
+}
+
+// ApplyMergePatch applies a JSON merge patch (RFC 7386) onto out with the options of the Mapper
+func (m *Mapper) ApplyMergePatch(patch map[string]interface{}, out interface{}) error {
+	mm := *m
+	mm.mergePatch = true
+	return mm.Teepr(patch, out)
+}
+
+// isNull reports whether a map value is a JSON null
+func isNull(val reflect.Value) bool {
+	if val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return val.IsNil()
+	}
+	return false
+}
+
+// isPatchObject reports whether a map value is a JSON object merged recursively
+func isPatchObject(val reflect.Value) bool {
+	if val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	return val.Kind() == reflect.Map && !val.IsNil()
+}
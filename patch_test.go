@@ -0,0 +1,88 @@
+package teepr
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type PatchableUser struct {
+	FirstName      string
+	LastName       string
+	Email          *string
+	Authentication *Authentication
+	Attributes     map[string]interface{}
+	Tags           []string
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	t.Log("Testing merge patch onto an existing struct")
+	{
+		email := "first@example.com"
+		user := PatchableUser{
+			FirstName: "firstex",
+			LastName:  "lastex",
+			Email:     &email,
+			Authentication: &Authentication{
+				Username: "xiexample",
+				APIToken: "apitoken",
+			},
+			Attributes: map[string]interface{}{
+				"vendor": "Numerindo",
+				"phone":  "081123480",
+				"address": map[string]interface{}{
+					"city": "Jakarta",
+					"zip":  "10110",
+				},
+			},
+			Tags: []string{"a", "b"},
+		}
+
+		body := `{
+			"LastName": "newlast",
+			"Email": null,
+			"Authentication": {"APIToken": "newtoken"},
+			"Attributes": {"phone": null, "channel": "mobile", "address": {"zip": null}},
+			"Tags": ["c"]
+		}`
+		patch := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(body), &patch); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		err := ApplyMergePatch(patch, &user)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		if user.FirstName != "firstex" || user.LastName != "newlast" || user.Email != nil {
+			t.Fatalf("%s expected LastName replaced and Email removed, got %+v", failed, user)
+		}
+		if user.Authentication.Username != "xiexample" || user.Authentication.APIToken != "newtoken" {
+			t.Fatalf("%s expected Authentication patched, got %+v", failed, user.Authentication)
+		}
+		if _, found := user.Attributes["phone"]; found || user.Attributes["vendor"] != "Numerindo" || user.Attributes["channel"] != "mobile" {
+			t.Fatalf("%s expected Attributes patched, got %v", failed, user.Attributes)
+		}
+		address := user.Attributes["address"].(map[string]interface{})
+		if _, found := address["zip"]; found || address["city"] != "Jakarta" {
+			t.Fatalf("%s expected nested address patched, got %v", failed, address)
+		}
+		if len(user.Tags) != 1 || user.Tags[0] != "c" {
+			t.Fatalf("%s expected Tags replaced, got %v", failed, user.Tags)
+		}
+		t.Logf("%s Result: %+v", success, user)
+	}
+
+	t.Log("Testing merge patch null on a value field")
+	{
+		user := PatchableUser{FirstName: "firstex", LastName: "lastex"}
+		err := ApplyMergePatch(map[string]interface{}{"FirstName": nil}, &user)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if user.FirstName != "" || user.LastName != "lastex" {
+			t.Fatalf("%s expected FirstName zeroed, got %+v", failed, user)
+		}
+		t.Logf("%s Result: %+v", success, user)
+	}
+}
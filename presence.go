@@ -0,0 +1,86 @@
+package teepr
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldSet is a set of dotted output field paths, e.g. "Authentication.Username"
+type FieldSet map[string]bool
+
+// Has reports whether the path is in the set
+func (s FieldSet) Has(path string) bool {
+	return s[path]
+}
+
+// Paths gives the sorted paths of the set
+func (s FieldSet) Paths() []string {
+	paths := make([]string, 0, len(s))
+	for p := range s {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// TeeprFields maps the value of input into output like Teepr and gives the paths of the output
+// fields given a value from the input, a field explicitly set to its zero value is recorded,
+// a field absent from the input is not
+func (m *Mapper) TeeprFields(input interface{}, output interface{}) (FieldSet, error) {
+	it := &iteration{Mapper: m, collection: m.collection, collectionKey: m.collectionKey, present: make(FieldSet)}
+	if err := it.teepr(input, output, ""); err != nil {
+		return it.present, err
+	}
+	it.validateValue(reflect.ValueOf(output), "")
+	if len(it.errs) > 0 {
+		return it.present, it.errs
+	}
+	return it.present, nil
+}
+
+// Presence, embedded in an output struct, records which fields of the struct were given
+// a value from the input during the last mapping, paths are relative to the struct
+type Presence struct {
+	fields FieldSet
+}
+
+// IsSet reports whether the field at path was given a value from the input
+func (p Presence) IsSet(path string) bool {
+	return p.fields.Has(path)
+}
+
+// SetFields gives the sorted paths of the fields given a value from the input
+func (p Presence) SetFields() []string {
+	return p.fields.Paths()
+}
+
+var presenceType = reflect.TypeOf(Presence{})
+
+// markSet records that the output field at path was given a value from the input
+func (it *iteration) markSet(path string) {
+	if it.present == nil {
+		it.present = make(FieldSet)
+	}
+	it.present[path] = true
+}
+
+// fillPresence sets the Presence fields of the struct oval mapped at path
+func (it *iteration) fillPresence(oval reflect.Value, path string) {
+	otyp := oval.Type()
+	for i := 0; i < otyp.NumField(); i++ {
+		if otyp.Field(i).Type != presenceType || !oval.Field(i).CanSet() {
+			continue
+		}
+
+		fields := make(FieldSet)
+		for p := range it.present {
+			if path == "" {
+				fields[p] = true
+			} else if strings.HasPrefix(p, path+FlattenSeparator) {
+				fields[strings.TrimPrefix(p, path+FlattenSeparator)] = true
+			}
+		}
+		oval.Field(i).Set(reflect.ValueOf(Presence{fields: fields}))
+	}
+}
@@ -0,0 +1,100 @@
+package teepr
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type UserPatchRequest struct {
+	Presence
+	FirstName      string
+	Title          string
+	Authentication AuthenticationPatch
+}
+
+type AuthenticationPatch struct {
+	Presence
+	Username string
+	APIToken string
+}
+
+func TestPresence(t *testing.T) {
+	t.Log("Testing embedded Presence tells explicit zero values from absent fields")
+	{
+		input := map[string]interface{}{
+			"FirstName": "",
+			"Authentication": map[string]interface{}{
+				"Username": "userex",
+			},
+		}
+
+		var output UserPatchRequest
+		if err := Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if !output.IsSet("FirstName") || output.IsSet("Title") {
+			t.Fatalf("%s expected FirstName set and Title absent, got %v", failed, output.SetFields())
+		}
+		if !output.IsSet("Authentication.Username") || output.IsSet("Authentication.APIToken") {
+			t.Fatalf("%s expected Authentication.Username set, got %v", failed, output.SetFields())
+		}
+		expected := []string{"Username"}
+		if !reflect.DeepEqual(output.Authentication.SetFields(), expected) {
+			t.Fatalf("%s expected nested presence %v, got %v", failed, expected, output.Authentication.SetFields())
+		}
+		t.Logf("%s Result: %v", success, output.SetFields())
+	}
+
+	t.Log("Testing TeeprFields records the fields set from a struct input")
+	{
+		input := UserExample{FirstName: "firstex", Authentication: Authentication{Username: "userex"}}
+		var output UserExample
+		set, err := NewMapper(WithMerge(MergeEmpty)).TeeprFields(input, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if !set.Has("FirstName") || !set.Has("Authentication.Username") {
+			t.Fatalf("%s expected FirstName and Authentication.Username set, got %v", failed, set.Paths())
+		}
+		if set.Has("LastName") || set.Has("Authentication.APIToken") {
+			t.Fatalf("%s expected skipped empty fields absent, got %v", failed, set.Paths())
+		}
+		t.Logf("%s Result: %v", success, set.Paths())
+	}
+
+	t.Log("Testing TeeprFields of a Mapper shared by concurrent calls")
+	{
+		mapper := NewMapper()
+		var wg sync.WaitGroup
+		sets := make([]FieldSet, 50)
+		errs := make([]error, len(sets))
+		for i := range sets {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				input := map[string]interface{}{"FirstName": "firstex"}
+				if i%2 == 1 {
+					input = map[string]interface{}{"LastName": "lastex"}
+				}
+				var output UserExample
+				sets[i], errs[i] = mapper.TeeprFields(input, &output)
+			}(i)
+		}
+		wg.Wait()
+
+		for i, set := range sets {
+			if errs[i] != nil {
+				t.Fatalf("%s expected error nil, got %s", failed, errs[i].Error())
+			}
+			expected := []string{"FirstName"}
+			if i%2 == 1 {
+				expected = []string{"LastName"}
+			}
+			if !reflect.DeepEqual(set.Paths(), expected) {
+				t.Fatalf("%s expected %v for call %d, got %v", failed, expected, i, set.Paths())
+			}
+		}
+		t.Logf("%s Result: %v", success, sets[0].Paths())
+	}
+}
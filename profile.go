@@ -0,0 +1,57 @@
+package teepr
+
+import (
+	"reflect"
+)
+
+// WithProfile maps with the field names and exclusions of the named profile,
+// given in the teepr tag as `teepr:"public:-,admin:api_token"`. Fields without
+// an entry for the profile keep their usual name
+func WithProfile(name string) Option {
+	return func(m *Mapper) {
+		m.profile = name
+	}
+}
+
+// profileFields lists the fields of a struct type, as structFields does,
+// without the fields excluded from the profile of the mapping
+func (it *iteration) profileFields(typ reflect.Type) []structField {
+	fields := structFields(typ)
+	if it.profile == "" {
+		return fields
+	}
+
+	kept := fields[:0]
+	for _, f := range fields {
+		if name, ok := profileName(f.StructField, it.profile); ok && name == "-" {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// keyOf gives the key of a field in the profile of the mapping, see fieldKey
+func (it *iteration) keyOf(field reflect.StructField) string {
+	if name, ok := profileName(field, it.profile); ok && field.PkgPath == "" {
+		if name == "-" {
+			return ""
+		}
+		return name
+	}
+	return fieldKey(field)
+}
+
+// findProfileField looks a field up by its name in the profile of the mapping
+func (it *iteration) findProfileField(fields []structField, key string) (structField, bool) {
+	if it.profile == "" {
+		return structField{}, false
+	}
+
+	for _, f := range fields {
+		if name, ok := profileName(f.StructField, it.profile); ok && name == key {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
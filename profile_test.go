@@ -0,0 +1,64 @@
+package teepr
+
+import (
+	"reflect"
+	"testing"
+)
+
+type AccountEntity struct {
+	Id           string
+	Username     string `teepr:"admin:user_name"`
+	APIToken     string `teepr:"public:-,admin:api_token"`
+	PasswordHash string `teepr:"public:-,admin:-" json:"password_hash"`
+}
+
+func TestProfile(t *testing.T) {
+	account := AccountEntity{Id: "acc123", Username: "userex", APIToken: "token", PasswordHash: "hash"}
+
+	t.Log("Testing the public and admin profiles into a map")
+	{
+		testCases := []struct {
+			profile  string
+			expected map[string]interface{}
+		}{
+			{"public", map[string]interface{}{"Id": "acc123", "Username": "userex"}},
+			{"admin", map[string]interface{}{"Id": "acc123", "user_name": "userex", "api_token": "token"}},
+			{"", map[string]interface{}{"Id": "acc123", "Username": "userex", "APIToken": "token", "PasswordHash": "hash"}},
+		}
+
+		for _, tc := range testCases {
+			output := make(map[string]interface{})
+			if err := NewMapper(WithProfile(tc.profile)).Teepr(account, &output); err != nil {
+				t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+			}
+			if !reflect.DeepEqual(output, tc.expected) {
+				t.Fatalf("%s expected profile %q gives %v, got %v", failed, tc.profile, tc.expected, output)
+			}
+			t.Logf("%s Result %q: %v", success, tc.profile, output)
+		}
+	}
+
+	t.Log("Testing profiles on a map input")
+	{
+		input := map[string]interface{}{"api_token": "changed", "password_hash": "changed"}
+		var output AccountEntity
+		if err := NewMapper(WithProfile("admin")).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.APIToken != "changed" || output.PasswordHash != "" {
+			t.Fatalf("%s expected only APIToken set, got %+v", failed, output)
+		}
+
+		input = map[string]interface{}{"Id": "acc123", "APIToken": "changed"}
+		output = AccountEntity{}
+		err := NewMapper(WithProfile("public"), WithStrictFields()).Teepr(input, &output)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != 1 || !hasFieldError(errs, "Username", ErrUnsetField) {
+			t.Fatalf("%s expected only Username reported unset, got %v", failed, err)
+		}
+		if output.APIToken != "" {
+			t.Fatalf("%s expected excluded fields ignored, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+}
@@ -0,0 +1,93 @@
+package teepr
+
+import (
+	"log"
+	"reflect"
+	"strings"
+)
+
+// ProtectionPolicy tells what happens to a write to a protected field from untrusted input
+type ProtectionPolicy int
+
+const (
+	// IgnoreProtected skips the write and logs the attempt
+	IgnoreProtected ProtectionPolicy = iota
+	// RejectProtected skips the write and fails the mapping with ErrReadOnly at the field path
+	RejectProtected
+)
+
+// WithUntrustedInput treats the input as untrusted, like a request body bound into an entity.
+// Fields tagged `teepr:",readonly"` and the fields at the protected dotted paths, which may use
+// MaskWildcard, are protected from writes following policy
+func WithUntrustedInput(policy ProtectionPolicy, protected ...string) Option {
+	return func(m *Mapper) {
+		m.untrusted = true
+		m.protection = policy
+		for _, p := range protected {
+			m.protected = append(m.protected, strings.Split(p, FlattenSeparator))
+		}
+	}
+}
+
+// WithWritable treats the input as untrusted and makes the listed dotted paths, which may use
+// MaskWildcard, the only writable ones, every other field and everything below it is protected.
+// Writes to protected fields follow the policy of WithUntrustedInput, IgnoreProtected by default
+func WithWritable(paths ...string) Option {
+	return func(m *Mapper) {
+		m.untrusted = true
+		for _, p := range paths {
+			m.writable = append(m.writable, strings.Split(p, FlattenSeparator))
+		}
+	}
+}
+
+// isProtected reports whether the output field at path cannot be written from the input
+func (it *iteration) isProtected(field reflect.StructField, path string) bool {
+	if !it.untrusted {
+		return false
+	}
+	if hasTagOption(field, "readonly") {
+		return true
+	}
+
+	segments := strings.Split(path, FlattenSeparator)
+	for _, p := range it.protected {
+		if len(segments) >= len(p) && matchSegments(p, segments) {
+			return true
+		}
+	}
+	if len(it.writable) == 0 {
+		return false
+	}
+	for _, w := range it.writable {
+		if matchSegments(w, segments) {
+			return false
+		}
+	}
+	return true
+}
+
+// partlyWritable reports whether only some of the values below path are writable,
+// such values are mapped onto the existing output to leave the rest untouched
+func (it *iteration) partlyWritable(path string) bool {
+	if !it.untrusted || len(it.writable) == 0 {
+		return false
+	}
+
+	segments := strings.Split(path, FlattenSeparator)
+	for _, w := range it.writable {
+		if len(w) <= len(segments) && matchSegments(w, segments) {
+			return false
+		}
+	}
+	return true
+}
+
+// protectedWrite reports an attempt to write the protected field at path
+func (it *iteration) protectedWrite(path string) {
+	if it.protection == RejectProtected {
+		it.addError(path, ErrReadOnly)
+		return
+	}
+	log.Println("[Teepr]", "ignored write to read-only field", path)
+}
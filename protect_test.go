@@ -0,0 +1,94 @@
+package teepr
+
+import (
+	"testing"
+)
+
+type CustomerEntity struct {
+	BaseEntity
+	Id    string `teepr:",readonly"`
+	Name  string
+	Email string
+}
+
+func TestUntrustedInput(t *testing.T) {
+	input := map[string]interface{}{
+		"Id":        "injected",
+		"Name":      "firstex",
+		"CreatedBy": "injected",
+	}
+
+	t.Log("Testing writes to protected fields are ignored")
+	{
+		output := CustomerEntity{Id: "cus123", BaseEntity: BaseEntity{CreatedBy: "user1"}}
+		if err := NewMapper(WithUntrustedInput(IgnoreProtected, "CreatedBy")).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Id != "cus123" || output.CreatedBy != "user1" || output.Name != "firstex" {
+			t.Fatalf("%s expected protected fields untouched, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing writes to protected fields are rejected")
+	{
+		output := CustomerEntity{Id: "cus123"}
+		err := NewMapper(WithUntrustedInput(RejectProtected, "CreatedBy")).Teepr(input, &output)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != 2 || !hasFieldError(errs, "Id", ErrReadOnly) || !hasFieldError(errs, "CreatedBy", ErrReadOnly) {
+			t.Fatalf("%s expected ErrReadOnly at Id and CreatedBy, got %v", failed, err)
+		}
+		if output.Id != "cus123" {
+			t.Fatalf("%s expected Id = cus123, got %s", failed, output.Id)
+		}
+		t.Logf("%s Result: %s", success, err.Error())
+	}
+
+	t.Log("Testing readonly tag is ignored for trusted input")
+	{
+		var output CustomerEntity
+		if err := Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Id != "injected" {
+			t.Fatalf("%s expected Id = injected, got %s", failed, output.Id)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing only writable fields are written from untrusted input")
+	{
+		input := map[string]interface{}{
+			"FirstName": "firstex",
+			"Email":     "injected",
+			"Authentication": map[string]interface{}{
+				"Username": "userex",
+				"APIToken": "injected",
+			},
+		}
+		output := UserExample{Email: "first@example.com", Authentication: Authentication{APIToken: "token"}}
+		err := NewMapper(WithUntrustedInput(RejectProtected), WithWritable("FirstName", "Authentication.Username")).Teepr(input, &output)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != 2 || !hasFieldError(errs, "Email", ErrReadOnly) || !hasFieldError(errs, "Authentication.APIToken", ErrReadOnly) {
+			t.Fatalf("%s expected ErrReadOnly at Email and Authentication.APIToken, got %v", failed, err)
+		}
+		if output.FirstName != "firstex" || output.Email != "first@example.com" || output.Authentication.APIToken != "token" || output.Authentication.Username != "userex" {
+			t.Fatalf("%s expected only writable fields written, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %s", success, err.Error())
+	}
+
+	t.Log("Testing writable fields of a struct input leave the rest untouched")
+	{
+		input := UserExample{FirstName: "firstex", LastName: "injected", Authentication: Authentication{Username: "userex", APIToken: "injected"}}
+		output := UserExample{LastName: "lastex", Authentication: Authentication{APIToken: "token"}}
+		if err := NewMapper(WithWritable("FirstName", "Authentication.Username")).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.FirstName != "firstex" || output.LastName != "lastex" ||
+			output.Authentication.Username != "userex" || output.Authentication.APIToken != "token" {
+			t.Fatalf("%s expected only writable fields written, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+}
@@ -0,0 +1,194 @@
+package teepr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"sync"
+)
+
+// Redaction tells how the values of sensitive fields are written during a mapping
+type Redaction int
+
+const (
+	// RedactNone writes sensitive values as they are
+	RedactNone Redaction = iota
+	// RedactMask replaces sensitive strings with RedactionMask, other values with their zero value
+	RedactMask
+	// RedactHash replaces sensitive strings with their hex encoded SHA-256, other values with their zero value
+	RedactHash
+	// RedactZero replaces sensitive values with their zero value
+	RedactZero
+)
+
+// RedactionMask is the string written in place of a sensitive string by RedactMask
+var RedactionMask = "******"
+
+// WithRedaction redacts the values of the fields tagged `teepr:",sensitive"`,
+// and of the fields and types marked with RegisterSensitive
+func WithRedaction(mode Redaction) Option {
+	return func(m *Mapper) {
+		m.redaction = mode
+	}
+}
+
+var sensitive = struct {
+	sync.RWMutex
+	types  map[reflect.Type]bool
+	fields map[reflect.Type]map[string]bool
+}{
+	types:  make(map[reflect.Type]bool),
+	fields: make(map[reflect.Type]map[string]bool),
+}
+
+// RegisterSensitive marks fields of the type of value as sensitive, for types whose tags
+// cannot be changed. Without field names every value of the type is sensitive
+func RegisterSensitive(value interface{}, fields ...string) {
+	typ := reflect.TypeOf(value)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	sensitive.Lock()
+	defer sensitive.Unlock()
+	if len(fields) == 0 {
+		sensitive.types[typ] = true
+		return
+	}
+	if sensitive.fields[typ] == nil {
+		sensitive.fields[typ] = make(map[string]bool)
+	}
+	for _, f := range fields {
+		sensitive.fields[typ][f] = true
+	}
+}
+
+// isSensitiveType reports whether the type was marked sensitive as a whole
+func isSensitiveType(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	sensitive.RLock()
+	defer sensitive.RUnlock()
+	return sensitive.types[typ]
+}
+
+// isSensitive reports whether the field of the struct type owner holds a sensitive value
+func isSensitive(owner reflect.Type, field reflect.StructField) bool {
+	if hasTagOption(field, "sensitive") || isSensitiveType(field.Type) {
+		return true
+	}
+
+	sensitive.RLock()
+	defer sensitive.RUnlock()
+	return sensitive.fields[owner][field.Name]
+}
+
+// redact gives the redacted copy of a sensitive value, structs keep their shape
+// with every field redacted
+func (it *iteration) redact(val reflect.Value) reflect.Value {
+	out := reflect.New(val.Type()).Elem()
+	if it.redaction == RedactZero {
+		return out
+	}
+
+	switch val.Kind() {
+	case reflect.String:
+		if it.redaction == RedactHash {
+			sum := sha256.Sum256([]byte(val.String()))
+			out.SetString(hex.EncodeToString(sum[:]))
+		} else if val.Len() > 0 {
+			out.SetString(RedactionMask)
+		}
+	case reflect.Ptr:
+		if !val.IsNil() {
+			out.Set(reflect.New(val.Type().Elem()))
+			out.Elem().Set(it.redact(val.Elem()))
+		}
+	case reflect.Struct:
+		if isLeafStruct(val.Type()) {
+			break
+		}
+		for _, f := range structFields(val.Type()) {
+			if fval, ok := fieldByIndex(val, f.index, false); ok {
+				if fout, ok := fieldByIndex(out, f.index, true); ok {
+					fout.Set(it.redact(fval))
+				}
+			}
+		}
+	}
+	return out
+}
+
+// scrub gives a copy of val where the sensitive values found at any depth are redacted,
+// values holding nothing sensitive are returned as they are
+func (it *iteration) scrub(val reflect.Value, seen map[visit]bool) reflect.Value {
+	if it.redaction == RedactNone || !val.IsValid() {
+		return val
+	}
+	if isSensitiveType(val.Type()) {
+		return it.redact(val)
+	}
+
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			break
+		}
+		key := visit{val.Pointer(), val.Type(), 0}
+		if seen[key] {
+			break
+		}
+		seen[key] = true
+		out := reflect.New(val.Type().Elem())
+		out.Elem().Set(it.scrub(val.Elem(), seen))
+		delete(seen, key)
+		return out
+	case reflect.Interface:
+		if val.IsNil() {
+			break
+		}
+		out := reflect.New(val.Type()).Elem()
+		out.Set(it.scrub(val.Elem(), seen))
+		return out
+	case reflect.Struct:
+		if isLeafStruct(val.Type()) {
+			break
+		}
+		out := reflect.New(val.Type()).Elem()
+		out.Set(val)
+		for _, f := range structFields(val.Type()) {
+			fval, ok := fieldByIndex(val, f.index, false)
+			if !ok {
+				continue
+			}
+			fout, _ := fieldByIndex(out, f.index, true)
+			if isSensitive(val.Type(), f.StructField) {
+				fout.Set(it.redact(fval))
+			} else {
+				fout.Set(it.scrub(fval, seen))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if val.IsNil() {
+			break
+		}
+		out := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+		for i := 0; i < val.Len(); i++ {
+			out.Index(i).Set(it.scrub(val.Index(i), seen))
+		}
+		return out
+	case reflect.Map:
+		if val.IsNil() {
+			break
+		}
+		out := reflect.MakeMap(val.Type())
+		for _, k := range val.MapKeys() {
+			out.SetMapIndex(k, it.scrub(val.MapIndex(k), seen))
+		}
+		return out
+	}
+	return val
+}
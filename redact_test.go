@@ -0,0 +1,81 @@
+package teepr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+type CredentialView struct {
+	Username  string
+	APISecret string `teepr:",sensitive"`
+	APIToken  string `teepr:",sensitive"`
+}
+
+type PaymentCard struct {
+	Number string
+	Holder string
+}
+
+type PaymentLog struct {
+	OrderId string
+	Card    PaymentCard
+}
+
+func TestRedaction(t *testing.T) {
+	t.Log("Testing sensitive tag on the output fields with RedactMask")
+	{
+		input := Authentication{Username: "userex", APISecret: "secret", APIToken: "token"}
+		var output CredentialView
+		if err := NewMapper(WithRedaction(RedactMask)).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Username != "userex" || output.APISecret != RedactionMask || output.APIToken != RedactionMask {
+			t.Fatalf("%s expected sensitive fields masked, got %+v", failed, output)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing sensitive tag is ignored without a redaction mode")
+	{
+		input := map[string]interface{}{"Username": "userex", "APIToken": "token"}
+		var output CredentialView
+		if err := Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.APIToken != "token" {
+			t.Fatalf("%s expected APIToken = token, got %s", failed, output.APIToken)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing registered fields of a type with RedactHash into a log map")
+	{
+		RegisterSensitive(SecretDetailEx{}, "APISecret", "APIToken")
+		input := SecretDetailEx{Id: "sec123", APISecret: "secret", APIToken: "token"}
+		output := make(map[string]interface{})
+		if err := NewMapper(WithRedaction(RedactHash)).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		sum := sha256.Sum256([]byte("secret"))
+		if output["Id"] != "sec123" || output["APISecret"] != hex.EncodeToString(sum[:]) {
+			t.Fatalf("%s expected APISecret hashed, got %v", failed, output)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing a registered type nested in a log map with RedactZero")
+	{
+		RegisterSensitive(PaymentCard{})
+		input := PaymentLog{OrderId: "ord123", Card: PaymentCard{Number: "4111111111111111", Holder: "firstex"}}
+		output := make(map[string]interface{})
+		if err := NewMapper(WithRedaction(RedactZero)).Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		card, ok := output["Card"].(map[string]interface{})
+		if !ok || card["Number"] != "" || card["Holder"] != "" || output["OrderId"] != "ord123" {
+			t.Fatalf("%s expected Card zeroed, got %v", failed, output)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+}
@@ -0,0 +1,58 @@
+package teepr
+
+import (
+	"reflect"
+)
+
+// RequiredPolicy decides when a field tagged `teepr:",required"` is given no value by the input
+type RequiredPolicy int
+
+const (
+	// RequireKey needs the input to have a key or a field for the output field, this is the default
+	RequireKey RequiredPolicy = iota
+	// RequireNonNil also needs pointer, map, slice and interface fields to be non nil
+	RequireNonNil
+	// RequireNonEmpty also needs the field value not to be empty, see IsEmpty,
+	// nor to be a slice or a map without elements
+	RequireNonEmpty
+)
+
+// WithRequiredPolicy sets when a required field is reported missing
+func WithRequiredPolicy(policy RequiredPolicy) Option {
+	return func(m *Mapper) {
+		m.required = policy
+	}
+}
+
+// checkRequired reports every field of the struct oval tagged `teepr:",required"`
+// that was given no value by the input
+func (it *iteration) checkRequired(oval reflect.Value, path string) {
+	for _, f := range it.profileFields(oval.Type()) {
+		fpath := joinPath(path, f.Name)
+		if !hasTagOption(f.StructField, "required") || !it.inMask(fpath) || it.isProtected(f.StructField, fpath) {
+			continue
+		}
+		fval, _ := fieldByIndex(oval, f.index, false)
+		if !it.present.Has(fpath) || !fval.IsValid() ||
+			it.required >= RequireNonNil && isNilValue(fval) ||
+			it.required == RequireNonEmpty && isEmptyValue(fval) {
+			it.addError(fpath, ErrRequired)
+		}
+	}
+}
+
+func isEmptyValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Slice, reflect.Map:
+		return val.Len() == 0
+	}
+	return IsEmpty(val.Interface())
+}
+
+func isNilValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return val.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package teepr
+
+import (
+	"testing"
+)
+
+type OrderPlacedEvent struct {
+	OrderId  string       `teepr:",required"`
+	Customer *UserExample `teepr:",required"`
+	Items    []OrderItem  `teepr:",required"`
+	Note     string
+}
+
+func TestRequired(t *testing.T) {
+	t.Log("Testing required fields missing from a map input")
+	{
+		input := map[string]interface{}{"Note": "leave at the door"}
+		var output OrderPlacedEvent
+		err := Teepr(input, &output)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != 3 || !hasFieldError(errs, "OrderId", ErrRequired) ||
+			!hasFieldError(errs, "Customer", ErrRequired) || !hasFieldError(errs, "Items", ErrRequired) {
+			t.Fatalf("%s expected ErrRequired at OrderId, Customer and Items, got %v", failed, err)
+		}
+		t.Logf("%s Result: %s", success, err.Error())
+	}
+
+	t.Log("Testing required policies on explicit null and empty values")
+	{
+		input := map[string]interface{}{"OrderId": "", "Customer": nil, "Items": []interface{}{}}
+		testCases := []struct {
+			policy  RequiredPolicy
+			missing []string
+		}{
+			{RequireKey, nil},
+			{RequireNonNil, []string{"Customer"}},
+			{RequireNonEmpty, []string{"OrderId", "Customer", "Items"}},
+		}
+
+		for _, tc := range testCases {
+			var output OrderPlacedEvent
+			err := NewMapper(WithRequiredPolicy(tc.policy)).Teepr(input, &output)
+			errs, _ := err.(Errors)
+			if len(errs) != len(tc.missing) {
+				t.Fatalf("%s expected policy %d reports %v, got %v", failed, tc.policy, tc.missing, err)
+			}
+			for _, path := range tc.missing {
+				if !hasFieldError(errs, path, ErrRequired) {
+					t.Fatalf("%s expected policy %d reports %s, got %v", failed, tc.policy, path, err)
+				}
+			}
+			t.Logf("%s Result policy %d: %v", success, tc.policy, err)
+		}
+	}
+
+	t.Log("Testing required policies on nil and empty values of a struct input")
+	{
+		input := struct {
+			OrderId  string
+			Customer *UserExample
+			Items    []OrderItem
+		}{"", nil, []OrderItem{}}
+		testCases := []struct {
+			policy  RequiredPolicy
+			missing []string
+		}{
+			{RequireNonNil, []string{"Customer"}},
+			{RequireNonEmpty, []string{"OrderId", "Customer", "Items"}},
+		}
+
+		for _, tc := range testCases {
+			var output OrderPlacedEvent
+			err := NewMapper(WithRequiredPolicy(tc.policy)).Teepr(input, &output)
+			errs, _ := err.(Errors)
+			if len(errs) != len(tc.missing) {
+				t.Fatalf("%s expected policy %d reports %v, got %v", failed, tc.policy, tc.missing, err)
+			}
+			for _, path := range tc.missing {
+				if !hasFieldError(errs, path, ErrRequired) {
+					t.Fatalf("%s expected policy %d reports %s, got %v", failed, tc.policy, path, err)
+				}
+			}
+			if output.Customer != nil {
+				t.Fatalf("%s expected Customer left nil, got %+v", failed, output.Customer)
+			}
+			t.Logf("%s Result policy %d: %v", success, tc.policy, err)
+		}
+	}
+}
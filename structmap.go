@@ -0,0 +1,280 @@
+package teepr
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// structToMap writes every exported field of the struct ival into the map oval,
+// keyed by the teepr tag name or the field name. When the map elements are scalars,
+// nested structs, maps and slices are written at the dotted keys of their leaves
+func (it *iteration) structToMap(ival, oval reflect.Value, path string) error {
+	otyp := oval.Type()
+	if otyp.Key().Kind() != reflect.String {
+		return fmt.Errorf("[Teepr]expecting output map with string keys, got %s", otyp.String())
+	}
+
+	if oval.IsNil() {
+		oval.Set(reflect.MakeMap(otyp))
+	}
+
+	fields := it.profileFields(ival.Type())
+	remain, hasRemain := remainField(fields)
+	for _, f := range fields {
+		key := it.keyOf(f.StructField)
+		if key == "" || hasRemain && f.Name == remain.Name {
+			continue
+		}
+		fval, ok := fieldByIndex(ival, f.index, false)
+		if !ok || !it.inMask(joinPath(path, key)) {
+			continue
+		}
+		if it.redaction != RedactNone && isSensitive(ival.Type(), f.StructField) {
+			fval = it.redact(fval)
+		}
+
+		if err := it.putMapElem(oval, key, fval, path); err != nil {
+			return err
+		}
+	}
+
+	if rval, ok := fieldByIndex(ival, remain.index, false); hasRemain && ok {
+		for _, k := range rval.MapKeys() {
+			if oval.MapIndex(k.Convert(otyp.Key())).IsValid() || !it.inMask(joinPath(path, k.String())) {
+				continue
+			}
+			if err := it.putMapElem(oval, k.String(), rval.MapIndex(k), path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// putMapElem writes val at key into the map oval mapped at path, values without
+// a representation in the map element type are skipped
+func (it *iteration) putMapElem(oval reflect.Value, key string, val reflect.Value, path string) error {
+	otyp := oval.Type()
+	if !isScalarKind(otyp.Elem().Kind()) || !isNestedValue(val) {
+		elem, ok, err := it.toMapElem(val, otyp.Elem(), joinPath(path, key))
+		if ok && err == nil {
+			oval.SetMapIndex(reflect.ValueOf(key).Convert(otyp.Key()), elem)
+		}
+		return err
+	}
+
+	f := newFlattener()
+	f.flatten(reflect.ValueOf(it.toMapValue(it.scrub(val, make(map[visit]bool)))), key)
+	for k, v := range f.result {
+		if v == nil || !it.inMask(joinPath(path, k)) {
+			continue
+		}
+		elem, ok, err := it.toMapElem(reflect.ValueOf(v), otyp.Elem(), joinPath(path, k))
+		if err != nil {
+			return err
+		}
+		if ok {
+			oval.SetMapIndex(reflect.ValueOf(k).Convert(otyp.Key()), elem)
+		}
+	}
+	return nil
+}
+
+// toMapElem converts a field value into a value of the map element type.
+// It returns false when the value has no representation in that type
+func (it *iteration) toMapElem(val reflect.Value, typ reflect.Type, path string) (reflect.Value, bool, error) {
+	switch {
+	case typ.Kind() == reflect.Interface:
+		v := it.pruneMask(it.toMapValue(it.scrub(val, make(map[visit]bool))), path)
+		if v == nil {
+			return reflect.Zero(typ), true, nil
+		}
+		rv := reflect.ValueOf(v)
+		return rv, rv.Type().Implements(typ), nil
+	case typ.Kind() == reflect.String:
+		str, ok := toMapString(val)
+		return reflect.ValueOf(str).Convert(typ), ok, nil
+	case val.Type().AssignableTo(typ):
+		return val, true, nil
+	case isNumberKind(val.Kind()) && isNumberKind(typ.Kind()):
+		return val.Convert(typ), true, nil
+	case isScalarKind(typ.Kind()):
+		out, ok := toMapScalar(val, typ)
+		return out, ok, nil
+	default:
+		out := reflect.New(typ)
+		if err := it.teepr(val.Interface(), out.Interface(), path); err != nil {
+			return out.Elem(), false, err
+		}
+		return out.Elem(), true, nil
+	}
+}
+
+// toMapValue converts a value into its generic representation, structs become
+// map[string]interface{} and slices become []interface{}, recursively.
+// time.Time and the sql.Null* types are kept as they are
+func (it *iteration) toMapValue(val reflect.Value) interface{} {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		if isLeafStruct(val.Type()) {
+			break
+		}
+		result := make(map[string]interface{})
+		fields := it.profileFields(val.Type())
+		remain, hasRemain := remainField(fields)
+		for _, f := range fields {
+			key := it.keyOf(f.StructField)
+			if key == "" || hasRemain && f.Name == remain.Name {
+				continue
+			}
+			if fval, ok := fieldByIndex(val, f.index, false); ok {
+				result[key] = it.toMapValue(fval)
+			}
+		}
+		if rval, ok := fieldByIndex(val, remain.index, false); hasRemain && ok {
+			for _, k := range rval.MapKeys() {
+				if _, found := result[k.String()]; !found {
+					result[k.String()] = it.toMapValue(rval.MapIndex(k))
+				}
+			}
+		}
+		return result
+	case reflect.Slice:
+		if val.IsNil() {
+			return nil
+		}
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		result := make([]interface{}, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			result[i] = it.toMapValue(val.Index(i))
+		}
+		return result
+	case reflect.Map:
+		if val.IsNil() {
+			return nil
+		}
+		if val.Type().Key().Kind() != reflect.String {
+			break
+		}
+		result := make(map[string]interface{})
+		for _, k := range val.MapKeys() {
+			result[k.String()] = it.toMapValue(val.MapIndex(k))
+		}
+		return result
+	}
+
+	return val.Interface()
+}
+
+// toMapString gives the string representation of a scalar value,
+// time values are formatted with DefaultDateLayout
+func toMapString(val reflect.Value) (string, bool) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return "", false
+		}
+		val = val.Elem()
+	}
+
+	if dTime, ok := val.Interface().(time.Time); ok {
+		return dTime.Format(DefaultDateLayout), true
+	}
+	if stringer, ok := val.Interface().(fmt.Stringer); ok {
+		return stringer.String(), true
+	}
+
+	switch val.Kind() {
+	case reflect.String:
+		return val.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(val.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(val.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(val.Float(), 'f', -1, val.Type().Bits()), true
+	}
+
+	return "", false
+}
+
+// toMapScalar converts a scalar value into a number or bool type, strings are parsed.
+// It returns false when the value has no representation in that type
+func toMapScalar(val reflect.Value, typ reflect.Type) (reflect.Value, bool) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return reflect.Value{}, false
+		}
+		val = val.Elem()
+	}
+
+	switch {
+	case isNumberKind(val.Kind()) && isNumberKind(typ.Kind()),
+		val.Kind() == reflect.Bool && typ.Kind() == reflect.Bool:
+		return val.Convert(typ), true
+	case val.Kind() == reflect.String && isNumberKind(typ.Kind()):
+		if f, err := strconv.ParseFloat(val.String(), 64); err == nil {
+			return reflect.ValueOf(f).Convert(typ), true
+		}
+	case val.Kind() == reflect.String && typ.Kind() == reflect.Bool:
+		if b, err := strconv.ParseBool(val.String()); err == nil {
+			return reflect.ValueOf(b).Convert(typ), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// isNestedValue reports whether val holds nested values: a struct other than time.Time
+// and the sql.Null* types, a map with string keys or a slice other than []byte.
+// Values with a String method are kept whole
+func isNestedValue(val reflect.Value) bool {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return false
+		}
+		if _, ok := val.Interface().(fmt.Stringer); ok {
+			return false
+		}
+		val = val.Elem()
+	}
+	if _, ok := val.Interface().(fmt.Stringer); ok {
+		return false
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		return !isLeafStruct(val.Type())
+	case reflect.Map:
+		return val.Type().Key().Kind() == reflect.String
+	case reflect.Slice:
+		return val.Type().Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Bool || isNumberKind(kind)
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
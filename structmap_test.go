@@ -0,0 +1,176 @@
+package teepr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStructToMap(t *testing.T) {
+	now := time.Now()
+
+	t.Log("Testing struct input, output map[string]interface{}")
+	{
+		userEx := UserExample{
+			FirstName: "firstex",
+			Authentication: Authentication{
+				Username: "xiexample",
+				ServiceDetail: ServiceDetail{
+					Service: "aservicenm",
+					Cost:    15000.00,
+				},
+			},
+			CreatedAt: now,
+		}
+
+		output := map[string]interface{}{}
+		err := Teepr(userEx, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		if output["FirstName"] != "firstex" {
+			t.Fatalf("%s expected FirstName = firstex, got %v", failed, output["FirstName"])
+		}
+		if output["CreatedAt"] != now {
+			t.Fatalf("%s expected CreatedAt = %v, got %v", failed, now, output["CreatedAt"])
+		}
+		auth, ok := output["Authentication"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s expected Authentication is a map, got %T", failed, output["Authentication"])
+		}
+		detail, ok := auth["ServiceDetail"].(map[string]interface{})
+		if !ok || detail["Cost"] != 15000.00 {
+			t.Fatalf("%s expected ServiceDetail.Cost = 15000, got %v", failed, auth["ServiceDetail"])
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing struct with slice input, output map[string]interface{}")
+	{
+		order := OrderEx{
+			Id: "o123",
+			Items: []OrderItem{
+				{Id: "itm123", ItemName: "XL 2 Giga", Price: 150000},
+			},
+		}
+
+		output := map[string]interface{}{}
+		err := Teepr(order, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		items, ok := output["Items"].([]interface{})
+		if !ok || len(items) != 1 {
+			t.Fatalf("%s expected Items is a slice of 1 item, got %v", failed, output["Items"])
+		}
+		if item := items[0].(map[string]interface{}); item["ItemName"] != "XL 2 Giga" {
+			t.Fatalf("%s expected ItemName = XL 2 Giga, got %v", failed, item["ItemName"])
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing struct input, output map[string]string with teepr tag")
+	{
+		input := struct {
+			Id      int64     `teepr:"id"`
+			Status  string    `teepr:"status"`
+			Secret  string    `teepr:"-"`
+			Created time.Time `teepr:"created_at"`
+			Number  MyInt
+		}{
+			12, "OrderCreated", "secret", now, MyInt(5),
+		}
+
+		var output map[string]string
+		err := Teepr(input, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		if output["id"] != "12" || output["status"] != "OrderCreated" || output["Number"] != "5" {
+			t.Fatalf("%s expected id = 12, status = OrderCreated, Number = 5, got %v", failed, output)
+		}
+		if output["created_at"] != now.Format(DefaultDateLayout) {
+			t.Fatalf("%s expected created_at = %s, got %s", failed, now.Format(DefaultDateLayout), output["created_at"])
+		}
+		if _, found := output["Secret"]; found {
+			t.Fatalf("%s expected Secret is skipped", failed)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing struct input, output map[string]float64")
+	{
+		input := ServiceCost{"Premium MS Order", 15000}
+		input2 := struct {
+			Shipping float32
+			Total    float64
+		}{1500, 16500}
+
+		output := map[string]float64{}
+		err := Teepr(input2, &output)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output["Shipping"] != 1500 || output["Total"] != 16500 {
+			t.Fatalf("%s expected Shipping = 1500, Total = 16500, got %v", failed, output)
+		}
+
+		detail := map[string]ServiceCost{}
+		err = Teepr(struct{ Order ServiceCost }{input}, &detail)
+		if err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if detail["Order"] != input {
+			t.Fatalf("%s expected Order = %v, got %v", failed, input, detail["Order"])
+		}
+		t.Logf("%s Result: %v %v", success, output, detail)
+	}
+
+	t.Log("Testing struct with nested values input, output map[string]string")
+	{
+		input := struct {
+			Id    string
+			Auth  Authentication
+			Items []OrderItem
+		}{
+			Id:    "acc123",
+			Auth:  Authentication{Username: "userex"},
+			Items: []OrderItem{{Id: "itm123", Price: 150000}},
+		}
+
+		var output map[string]string
+		if err := Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output["Id"] != "acc123" || output["Auth.Username"] != "userex" || output["Auth.ServiceDetail.Service"] != "" {
+			t.Fatalf("%s expected Auth flattened into dotted keys, got %v", failed, output)
+		}
+		if output["Items.0.Id"] != "itm123" || output["Items.0.Price"] != "150000" {
+			t.Fatalf("%s expected Items flattened into dotted keys, got %v", failed, output)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+
+	t.Log("Testing struct input, output map[string]int skips unconvertible values")
+	{
+		input := struct {
+			A int
+			B string
+			C string
+		}{1, "x", "12"}
+
+		output := map[string]int{}
+		if err := Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if _, found := output["B"]; found {
+			t.Fatalf("%s expected B skipped, got %v", failed, output)
+		}
+		if output["A"] != 1 || output["C"] != 12 {
+			t.Fatalf("%s expected A = 1, C = 12, got %v", failed, output)
+		}
+		t.Logf("%s Result: %v", success, output)
+	}
+}
@@ -0,0 +1,119 @@
+package teepr
+
+import (
+	"reflect"
+	"strings"
+)
+
+const (
+	TagName = "teepr"
+)
+
+// teeprTag splits the teepr tag of a field into its name and its options,
+// e.g. `teepr:"name,omitempty"` gives "name" and ["omitempty"].
+// Profile entries, like "public:-", are left out
+func teeprTag(field reflect.StructField) (string, []string) {
+	tag, ok := field.Tag.Lookup(TagName)
+	if !ok {
+		return "", nil
+	}
+
+	split := splitTag(tag)
+	name := strings.TrimSpace(split[0])
+	if isProfileEntry(name) {
+		name = ""
+	}
+	var options []string
+	for _, o := range split[1:] {
+		if !isProfileEntry(o) {
+			options = append(options, o)
+		}
+	}
+	return name, options
+}
+
+// splitTag splits a teepr tag on its commas. A value holding commas must be put
+// in single quotes, e.g. `teepr:",regexp='^[0-9]{2,4}$'"`, the quotes are removed
+func splitTag(tag string) []string {
+	var split []string
+	var segment strings.Builder
+	quoted := false
+	for _, r := range tag {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			split = append(split, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteRune(r)
+		}
+	}
+	return append(split, segment.String())
+}
+
+// isProfileEntry reports whether a segment of a teepr tag names a field in a profile,
+// e.g. "admin:api_token", as opposed to an option like "collection=key:ItemID"
+func isProfileEntry(segment string) bool {
+	colon := strings.Index(segment, ":")
+	return colon > 0 && !strings.Contains(segment[:colon], "=")
+}
+
+// profileName gives the name of a field in a profile, e.g. `teepr:"public:-,admin:api_token"`
+// names the field "api_token" in the admin profile and excludes it from the public profile
+func profileName(field reflect.StructField, profile string) (string, bool) {
+	tag, ok := field.Tag.Lookup(TagName)
+	if !ok || profile == "" {
+		return "", false
+	}
+
+	for _, segment := range splitTag(tag) {
+		segment = strings.TrimSpace(segment)
+		if isProfileEntry(segment) && segment[:strings.Index(segment, ":")] == profile {
+			return segment[len(profile)+1:], true
+		}
+	}
+	return "", false
+}
+
+// hasTagOption reports whether the teepr tag of a field carries the option
+func hasTagOption(field reflect.StructField, option string) bool {
+	_, options := teeprTag(field)
+	for _, o := range options {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// tagValue gives the value of an option of the teepr tag of a field written as name=value,
+// e.g. `teepr:",default=PENDING"`
+func tagValue(field reflect.StructField, name string) (string, bool) {
+	_, options := teeprTag(field)
+	for _, o := range options {
+		o = strings.TrimSpace(o)
+		if strings.HasPrefix(o, name+"=") {
+			return strings.TrimPrefix(o, name+"="), true
+		}
+	}
+	return "", false
+}
+
+// fieldKey gives the key used for a field when it is written into a map,
+// the teepr tag name when there is one, otherwise the field name.
+// An empty key means the field must be skipped
+func fieldKey(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+
+	name, _ := teeprTag(field)
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
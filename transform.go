@@ -0,0 +1,143 @@
+package teepr
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// TransformFunc transforms a string, param is the value given to the transform
+// in the teepr tag, e.g. "64" for `teepr:",truncate=64"`
+type TransformFunc func(s, param string) string
+
+var transforms = struct {
+	sync.RWMutex
+	funcs map[string]TransformFunc
+}{
+	funcs: map[string]TransformFunc{
+		"trim":  func(s, _ string) string { return strings.TrimSpace(s) },
+		"lower": func(s, _ string) string { return strings.ToLower(s) },
+		"upper": func(s, _ string) string { return strings.ToUpper(s) },
+		"title": func(s, _ string) string {
+			prev := ' '
+			return strings.Map(func(r rune) rune {
+				if unicode.IsSpace(prev) {
+					r = unicode.ToTitle(r)
+				}
+				prev = r
+				return r
+			}, s)
+		},
+		"truncate": func(s, param string) string {
+			n, err := strconv.Atoi(param)
+			if runes := []rune(s); err == nil && n >= 0 && len(runes) > n {
+				return string(runes[:n])
+			}
+			return s
+		},
+		"collapse": func(s, _ string) string { return strings.Join(strings.Fields(s), " ") },
+		"digits": func(s, _ string) string {
+			return strings.Map(func(r rune) rune {
+				if unicode.IsDigit(r) {
+					return r
+				}
+				return -1
+			}, s)
+		},
+	},
+}
+
+// RegisterTransform adds a named string transform usable in every teepr tag,
+// next to the built-in trim, lower, upper, title, truncate=N, collapse and digits
+func RegisterTransform(name string, fn TransformFunc) {
+	transforms.Lock()
+	defer transforms.Unlock()
+	transforms.funcs[name] = fn
+}
+
+// WithTransform adds a named string transform usable in the teepr tags of this Mapper only
+func WithTransform(name string, fn TransformFunc) Option {
+	return func(m *Mapper) {
+		if m.transforms == nil {
+			m.transforms = make(map[string]TransformFunc)
+		}
+		m.transforms[name] = fn
+	}
+}
+
+func (it *iteration) lookupTransform(name string) (TransformFunc, bool) {
+	if fn, ok := it.transforms[name]; ok {
+		return fn, true
+	}
+
+	transforms.RLock()
+	defer transforms.RUnlock()
+	fn, ok := transforms.funcs[name]
+	return fn, ok
+}
+
+// transform applies, in order, the string transforms of the teepr tag of every field
+// of the struct oval given a value by the input, e.g. `teepr:"name,trim,lower"`.
+// Strings, string pointers and slices of strings are transformed
+func (it *iteration) transform(oval reflect.Value, path string) {
+	for _, f := range it.profileFields(oval.Type()) {
+		if !it.present.Has(joinPath(path, f.Name)) {
+			continue
+		}
+
+		_, options := teeprTag(f.StructField)
+		var fns []TransformFunc
+		var params []string
+		for _, o := range options {
+			name, param := strings.TrimSpace(o), ""
+			if i := strings.Index(name, "="); i >= 0 {
+				name, param = name[:i], name[i+1:]
+			}
+			if fn, ok := it.lookupTransform(name); ok {
+				fns, params = append(fns, fn), append(params, param)
+			}
+		}
+		if len(fns) == 0 {
+			continue
+		}
+
+		fval, ok := fieldByIndex(oval, f.index, false)
+		if !ok {
+			continue
+		}
+		applyTransforms(fval, fns, params)
+	}
+}
+
+// applyTransforms transforms the strings of val, pointers and slices are copied
+// first since they may be shared with the input
+func applyTransforms(val reflect.Value, fns []TransformFunc, params []string) {
+	switch val.Kind() {
+	case reflect.String:
+		s := val.String()
+		for i, fn := range fns {
+			s = fn(s, params[i])
+		}
+		val.SetString(s)
+	case reflect.Ptr:
+		if val.IsNil() || val.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		elem := reflect.New(val.Type().Elem())
+		elem.Elem().Set(val.Elem())
+		applyTransforms(elem.Elem(), fns, params)
+		val.Set(elem)
+	case reflect.Slice:
+		if val.IsNil() || val.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		elems := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+		reflect.Copy(elems, val)
+		for i := 0; i < elems.Len(); i++ {
+			applyTransforms(elems.Index(i), fns, params)
+		}
+		val.Set(elems)
+	}
+}
@@ -0,0 +1,81 @@
+package teepr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type InboundOrder struct {
+	Customer string   `teepr:"customer,trim,collapse,title"`
+	Email    string   `teepr:",trim,lower,email"`
+	Code     string   `teepr:",upper,truncate=6"`
+	Phone    *string  `teepr:",digits"`
+	Tags     []string `teepr:",trim,lower"`
+	Note     string   `teepr:",masked"`
+}
+
+func TestTransform(t *testing.T) {
+	t.Log("Testing built-in and registered transforms on a map input")
+	{
+		phone := "+62 (812) 3456-7890"
+		tags := []string{" Express ", "GIFT"}
+		input := map[string]interface{}{
+			"customer": "  first   example ",
+			"Email":    " UserEx@Example.com ",
+			"Code":     "promo2020x",
+			"Phone":    &phone,
+			"Tags":     tags,
+			"Note":     "call 0812",
+		}
+
+		var output InboundOrder
+		mapper := NewMapper(WithTransform("masked", func(s, _ string) string {
+			return strings.Repeat("*", len(s))
+		}))
+		if err := mapper.Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+
+		expected := InboundOrder{
+			Customer: "First Example",
+			Email:    "userex@example.com",
+			Code:     "PROMO2",
+			Tags:     []string{"express", "gift"},
+			Note:     "*********",
+		}
+		if output.Phone == nil || *output.Phone != "6281234567890" {
+			t.Fatalf("%s expected Phone = 6281234567890, got %v", failed, output.Phone)
+		}
+		output.Phone = nil
+		if !reflect.DeepEqual(output, expected) {
+			t.Fatalf("%s expected %+v, got %+v", failed, expected, output)
+		}
+		if phone != "+62 (812) 3456-7890" || tags[0] != " Express " {
+			t.Fatalf("%s expected the input untouched, got %s %v", failed, phone, tags)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing a transform registered for every mapping")
+	{
+		RegisterTransform("reverse", func(s, _ string) string {
+			runes := []rune(s)
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			return string(runes)
+		})
+
+		var output struct {
+			Code string `teepr:",reverse,upper"`
+		}
+		if err := Teepr(map[string]interface{}{"Code": "abc"}, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Code != "CBA" {
+			t.Fatalf("%s expected Code = CBA, got %s", failed, output.Code)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+}
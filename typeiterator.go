@@ -8,7 +8,6 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
-	"text/scanner"
 	"time"
 )
 
@@ -16,7 +15,15 @@ const (
 	DefaultDateLayout   = "2006-01-02 15:04:05"
 )
 
-func Teepr(input interface{}, output interface{}, customValues ...func(interface{}) (interface{}, error)) (err error) {
+// Teepr maps the value of input into output, output must be a pointer.
+// customValues are tried in order to convert values the built-in rules do not handle
+func Teepr(input interface{}, output interface{}, customValues ...func(interface{}) (interface{}, error)) error {
+	return NewMapper(WithCustomValues(customValues...)).Teepr(input, output)
+}
+
+// teepr is the conversion engine behind Teepr, path is the dotted path of output
+// in the value given to the top level call
+func (it *iteration) teepr(input interface{}, output interface{}, path string) (err error) {
 
 	// it is ok to be panicked
 	defer func() {
@@ -38,6 +45,21 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 	oval := reflect.Indirect(reflect.ValueOf(output))
 	otyp := oval.Type()
 
+	if oval.Kind() == reflect.Ptr && ival.Kind() != reflect.Map {
+		if oval.IsNil() {
+			oval.Set(reflect.New(otyp.Elem()))
+		}
+		return it.teepr(input, oval.Interface(), path)
+	}
+
+	if in := reflect.ValueOf(input); in.Kind() == reflect.Ptr {
+		if !it.enter(in, reflect.ValueOf(output)) {
+			it.addError(path, ErrCycle)
+			return nil
+		}
+		defer it.leave(in)
+	}
+
 	switch ival.Kind() {
 	case reflect.Map:
 
@@ -45,42 +67,78 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 			return fmt.Errorf("[Teepr]expecting output type of map or struct")
 		}
 
+		if oval.Kind() == reflect.Ptr && ival.Len() > 0 {
+			if oval.IsNil() {
+				tmpOval := reflect.New(oval.Type().Elem())
+				oval.Set(tmpOval)
+			}
+			oval = oval.Elem()
+			otyp = otyp.Elem()
+		}
+		if oval.Kind() == reflect.Struct && !it.beforeHooks(ival.Interface(), oval, path) {
+			return nil
+		}
+
+		matched := make(map[string]bool)
+		mergeElems := it.collection == MergeByIndex || it.collection == MergeByKey
 		for _, k := range ival.MapKeys() {
 			mival := ival.MapIndex(k)
 
-			if oval.Kind() == reflect.Ptr {
-				if oval.IsNil() {
-					tmpOval := reflect.New(oval.Type().Elem())
-					oval.Set(tmpOval)
-					oval = oval.Elem()
-					otyp = otyp.Elem()
-				}
-			}
-
 			if oval.Kind() == reflect.Struct {
-				foval := oval.FieldByName(k.String())
-				if !foval.IsValid() {
-					for s := 0; s < oval.NumField(); s++ {
-						oftype := otyp.Field(s)
-						oftag := string(oftype.Tag)
-						ofsplit := strings.Split(oftag, " ")
-
-						for _, split := range ofsplit {
-							osplit := strings.Split(split, ":")
-							if len(osplit) == 2 {
-								oosplit := strings.Replace(strings.Split(osplit[1], ",")[0], "\"", "", -1)
-								if k.String() == oosplit {
-									foval = oval.Field(s)
-									break
-								}
-							}
+				var foval reflect.Value
+				ofields := it.profileFields(otyp)
+				oftype, found := it.findProfileField(ofields, k.String())
+				if !found {
+					oftype, found = findField(ofields, k.String())
+				}
+				if !found {
+					oftype, found = findFieldByTag(ofields, k.String())
+				}
+				remain, hasRemain := remainField(ofields)
+				if found && hasRemain && oftype.Name == remain.Name {
+					found = false
+				}
+				if found {
+					foval, _ = fieldByIndex(oval, oftype.index, true)
+					matched[oftype.Name] = true
+				} else if hasRemain {
+					if rval, ok := fieldByIndex(oval, remain.index, true); ok {
+						if rval.IsNil() {
+							rval.Set(reflect.MakeMap(remainType))
 						}
-
+						rval.SetMapIndex(reflect.ValueOf(k.String()), mival.Convert(remainType.Elem()))
 					}
+				} else if it.strictKeys {
+					it.addError(joinPath(path, k.String()), ErrUnknownKey)
 				}
 				if !foval.IsValid() {
 					continue
 				}
+				fpath := joinPath(path, oftype.Name)
+				if !it.inMask(fpath) {
+					continue
+				}
+				if it.isProtected(oftype.StructField, fpath) {
+					it.protectedWrite(fpath)
+					continue
+				}
+				if it.mergePatch && isNull(mival) {
+					foval.Set(reflect.Zero(foval.Type()))
+					it.markSet(fpath)
+					continue
+				}
+				svalue := mival
+				if svalue.Kind() == reflect.Interface {
+					svalue = svalue.Elem()
+				}
+				if it.skipValue(svalue, oftype.StructField) {
+					continue
+				}
+				it.markSet(fpath)
+				if it.redaction != RedactNone && svalue.IsValid() &&
+					(isSensitive(otyp, oftype.StructField) || isSensitiveType(svalue.Type())) {
+					mival = it.redact(svalue)
+				}
 
 				if istr, ok := mival.Interface().(string); ok && foval.Kind() == reflect.String {
 					foval.Set(reflect.ValueOf(istr))
@@ -143,7 +201,7 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 					foval.Set(mival)
 				} else if mival.Kind() == reflect.Interface {
 					var isHandled bool
-					for _, c := range customValues {
+					for _, c := range it.customValues {
 						result, resultError := c(mival.Interface())
 						if resultError == nil && reflect.ValueOf(result).Type().String() == foval.Type().String() {
 							foval.Set(reflect.ValueOf(result))
@@ -155,22 +213,13 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 						elemival := reflect.Indirect(mival.Elem())
 
 						if elemival.Kind() == reflect.Slice && foval.Kind() == reflect.Slice {
-							mSlice := reflect.MakeSlice(foval.Type(), 0, elemival.Len())
-							for idx := 0; idx < elemival.Len(); idx++ {
-								theOutput := reflect.New(foval.Type().Elem())
-
-								tmpelemival := elemival.Index(idx)
-								if elemival.Index(idx).Kind() == reflect.Interface {
-									tmpelemival = elemival.Index(idx).Elem()
-								}
-								err = Teepr(tmpelemival.Interface(), theOutput.Interface(), customValues...)
-								mSlice = reflect.Append(mSlice, theOutput.Elem())
-							}
-							foval.Set(mSlice)
+							it.useCollection(oftype.StructField)
+							err = it.mapSlice(elemival, foval, fpath)
 						} else {
 							if mival.Interface() != nil {
 								pval := reflect.Indirect(mival.Elem())
-								err = Teepr(pval.Interface(), foval.Addr().Interface(), customValues...)
+								it.useCollection(oftype.StructField)
+								err = it.teepr(pval.Interface(), foval.Addr().Interface(), fpath)
 								if err != nil {
 									log.Println("[Teepr]", err.Error())
 									return
@@ -182,7 +231,18 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 					foval.Set(mival)
 				}
 			} else { // assumes output of type Map
-				if ityp.Elem().String() == otyp.Elem().String() {
+				if oval.IsNil() && oval.CanSet() {
+					oval.Set(reflect.MakeMap(otyp))
+				}
+				if !it.inMask(joinPath(path, k.String())) {
+					continue
+				}
+
+				if existing := oval.MapIndex(k); it.mergePatch && isNull(mival) {
+					oval.SetMapIndex(k, reflect.Value{})
+				} else if it.mergePatch && existing.IsValid() && isPatchObject(mival) && isPatchObject(existing) {
+					err = it.teepr(mival.Interface(), existing.Interface(), joinPath(path, k.String()))
+				} else if ityp.Elem().String() == otyp.Elem().String() && !(mergeElems && otyp.Elem().Kind() == reflect.Struct) {
 					oval.SetMapIndex(k, mival)
 				} else {
 					switch otyp.Elem().String() {
@@ -335,7 +395,10 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 					default:
 						if otyp.Elem().Kind() == reflect.Struct {
 							vvtyp := reflect.New(otyp.Elem())
-							eerr := Teepr(mival.Interface(), vvtyp.Interface(), customValues...)
+							if existing := oval.MapIndex(k); mergeElems && existing.IsValid() {
+								vvtyp.Elem().Set(existing)
+							}
+							eerr := it.teepr(mival.Interface(), vvtyp.Interface(), joinPath(path, k.String()))
 
 							if eerr != nil {
 								log.Println("[Teepr]", err.Error())
@@ -351,46 +414,67 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 			}
 		}
 
+		if oval.Kind() == reflect.Struct {
+			it.finishStruct(ival.Interface(), oval, path, matched)
+		}
 
 		return
 	case reflect.Struct:
 
-		if oval.Kind() != reflect.Struct {
+		if isLeafStruct(ityp) && ityp == otyp {
+			oval.Set(ival)
+			return nil
+		} else if oval.Kind() == reflect.Map {
+			return it.structToMap(ival, oval, path)
+		} else if oval.Kind() != reflect.Struct {
 			return fmt.Errorf("expecting output type of struct")
 		} else {
+			if !it.beforeHooks(ival.Interface(), oval, path) {
+				return nil
+			}
 
-			for i := 0; i < ival.NumField(); i++ {
+			ofields := it.profileFields(otyp)
+			matched := make(map[string]bool)
+			for _, ftin := range it.profileFields(ityp) {
 
-				fin := ival.Field(i)
-				ftin := ityp.Field(i)
+				fin, ok := fieldByIndex(ival, ftin.index, false)
+				if !ok {
+					continue
+				}
 
 				var fout reflect.Value
-				var ftout reflect.StructField
-
-				if fout = oval.FieldByName(ftin.Name); !fout.IsValid() {
-					for j := 0; j < oval.NumField(); j++ {
-						ftout = otyp.Field(j)
-						if itag, otag := ftin.Tag, ftout.Tag; itag != "" && otag != "" {
-							var scanner1 scanner.Scanner
-							scanner1.Init(strings.NewReader(string(itag)))
-
-							for tok := scanner1.Scan(); tok != scanner.EOF; tok = scanner1.Scan() {
-								switch tok {
-								case scanner.String:
-									text := scanner1.TokenText()
-									if strings.Contains(string(otag), text) {
-										fout = oval.Field(j)
-										break
-									}
-								}
-							}
-						}
-					}
+
+				ftout, found := findField(ofields, ftin.Name)
+				if !found {
+					ftout, found = matchFieldByTag(ofields, ftin.StructField)
+				}
+				if found {
+					fout, _ = fieldByIndex(oval, ftout.index, true)
 				}
 
 				if !fout.IsValid() {
 					continue
 				}
+				fpath := joinPath(path, ftout.Name)
+				if !it.inMask(fpath) {
+					continue
+				}
+				matched[ftout.Name] = true
+				if it.isProtected(ftout.StructField, fpath) {
+					it.protectedWrite(fpath)
+					continue
+				}
+				if it.skipValue(fin, ftin.StructField, ftout.StructField) {
+					continue
+				}
+				it.markSet(fpath)
+				if it.redaction != RedactNone {
+					if isSensitive(ityp, ftin.StructField) || isSensitive(otyp, ftout.StructField) {
+						fin = it.redact(fin)
+					} else if fout.Kind() == reflect.Interface {
+						fin = it.scrub(fin, make(map[visit]bool))
+					}
+				}
 
 				if fout.Kind() == reflect.Interface {
 					fout.Set(fin)
@@ -449,13 +533,18 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 						fout.Set(reflect.ValueOf(data.Time))
 					}
 				} else if fin.Kind() == reflect.Map {
-					err = Teepr(fin.Interface(), fout.Interface(), customValues...)
+					it.useCollection(ftout.StructField)
+					err = it.teepr(fin.Interface(), fout.Interface(), fpath)
 					if err != nil {
 						log.Println("[Teepr]", err.Error())
 						return err
 					}
 				} else {
 
+					if isNilValue(fin) && !it.partlyMasked(fpath) && !it.partlyWritable(fpath) {
+						fout.Set(reflect.Zero(fout.Type()))
+						continue
+					}
 					if fout.IsValid() && fin.IsValid() {
 						var atype reflect.Type
 						var abool bool
@@ -466,9 +555,22 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 							atype = fout.Type()
 							abool = false
 						}
+						if ref, found := it.sharedRef(fin, fout.Type()); abool && found {
+							fout.Set(ref)
+							continue
+						}
+
 						iout := reflect.New(atype)
+						it.useCollection(ftout.StructField)
+						if it.merge != MergeNone || atype.Kind() == reflect.Slice || atype.Kind() == reflect.Map || it.partlyMasked(fpath) || it.partlyWritable(fpath) {
+							if abool && !fout.IsNil() {
+								iout = fout
+							} else if !abool {
+								iout.Elem().Set(fout)
+							}
+						}
 
-						err = Teepr(fin.Interface(), iout.Interface(), customValues...)
+						err = it.teepr(fin.Interface(), iout.Interface(), fpath)
 						if err != nil {
 							log.Println("[Teepr]", err.Error())
 							return
@@ -482,6 +584,7 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 				}
 
 			}
+			it.finishStruct(ival.Interface(), oval, path, matched)
 		}
 
 		return nil
@@ -490,19 +593,11 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 		if oval.Kind() == reflect.Interface {
 			oval.Set(ival)
 		} else if oval.Kind() == reflect.Slice {
-			outSlice := reflect.MakeSlice(reflect.SliceOf(otyp.Elem()), 0, ival.Len())
-			for i := 0; i < ival.Len(); i++ {
-
-				oItem := reflect.New(otyp.Elem())
-				iItem := ival.Index(i)
-				err = Teepr(iItem.Interface(), oItem.Interface(), customValues...)
-				if err != nil {
-					log.Println("[Teepr]", err.Error())
-					return
-				}
-				outSlice = reflect.Append(outSlice, oItem.Elem())
+			err = it.mapSlice(ival, oval, path)
+			if err != nil {
+				log.Println("[Teepr]", err.Error())
+				return
 			}
-			oval.Set(outSlice)
 		}
 	case reflect.Array:
 		if oval.Kind() == reflect.Interface {
@@ -633,7 +728,7 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 				}
 			}
 		} else {
-			for i, c := range customValues {
+			for i, c := range it.customValues {
 				result, resultError := c(ival.Interface())
 				if resultError == nil && reflect.ValueOf(result).Type().String() == oval.Type().String() {
 					oval.Set(reflect.ValueOf(result))
@@ -646,7 +741,7 @@ func Teepr(input interface{}, output interface{}, customValues ...func(interface
 		return nil
 	case reflect.Interface:
 		pival := ival.Elem()
-		err = Teepr(pival.Interface(), output, customValues...)
+		err = it.teepr(pival.Interface(), output, path)
 		if err != nil {
 			log.Println("[Teepr]Error: ", err)
 			return
@@ -0,0 +1,225 @@
+package teepr
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// RuleError is the error of a value breaking a validation rule of the teepr tag
+type RuleError struct {
+	Rule  string
+	Param string
+}
+
+func (e *RuleError) Error() string {
+	if e.Param == "" {
+		return fmt.Sprintf("%s: %s", ErrInvalid.Error(), e.Rule)
+	}
+	return fmt.Sprintf("%s: %s=%s", ErrInvalid.Error(), e.Rule, e.Param)
+}
+
+func (e *RuleError) Unwrap() error {
+	return ErrInvalid
+}
+
+// Validator is implemented by output types checking their own invariants, Validate is called
+// once the fields of the value are mapped, at any nesting level including slice elements.
+// A value that is not a struct is validated when the input gives it a value
+type Validator interface {
+	Validate() error
+}
+
+var (
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	patterns     sync.Map
+)
+
+// validate checks the fields of the struct oval against the rules of their teepr tag,
+// `teepr:",min=3,max=64"` bounds numbers, and the length of strings, slices and maps,
+// len gives an exact length, oneof lists the allowed values separated by spaces,
+// regexp, email and uuid check strings. Fields the input gave no value to and left zero
+// are not checked, see `teepr:",required"`. Tag options are split on commas, so a rule
+// value holding commas must be put in single quotes, e.g. `teepr:",regexp='^[0-9]{2,4}$'"`
+func (it *iteration) validate(oval reflect.Value, path string) {
+	for _, f := range it.profileFields(oval.Type()) {
+		fpath := joinPath(path, f.Name)
+		if !it.inMask(fpath) {
+			continue
+		}
+		fval, ok := fieldByIndex(oval, f.index, false)
+		if !ok || !it.present.Has(fpath) && fval.IsZero() {
+			continue
+		}
+		for fval.Kind() == reflect.Ptr || fval.Kind() == reflect.Interface {
+			if fval.IsNil() {
+				break
+			}
+			fval = fval.Elem()
+		}
+		if fval.Kind() == reflect.Ptr || fval.Kind() == reflect.Interface {
+			continue
+		}
+
+		_, options := teeprTag(f.StructField)
+		for _, o := range options {
+			rule, param := strings.TrimSpace(o), ""
+			if i := strings.Index(rule, "="); i >= 0 {
+				rule, param = rule[:i], rule[i+1:]
+			}
+			if err := checkRule(fval, rule, param); err != nil {
+				it.addError(fpath, err)
+			}
+		}
+	}
+}
+
+// checkRule checks a value against one rule, options that are not rules pass
+func checkRule(val reflect.Value, rule, param string) error {
+	switch rule {
+	case "min", "max", "len":
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return err
+		}
+		var size float64
+		switch {
+		case val.Kind() == reflect.String || val.Kind() == reflect.Slice || val.Kind() == reflect.Map || val.Kind() == reflect.Array:
+			size = float64(val.Len())
+		case isNumberKind(val.Kind()) && rule != "len":
+			size = val.Convert(reflect.TypeOf(float64(0))).Float()
+		default:
+			return nil
+		}
+		if rule == "min" && size < bound || rule == "max" && size > bound || rule == "len" && size != bound {
+			return &RuleError{Rule: rule, Param: param}
+		}
+	case "oneof":
+		str, ok := toMapString(val)
+		if !ok {
+			return nil
+		}
+		for _, allowed := range strings.Fields(param) {
+			if str == allowed {
+				return nil
+			}
+		}
+		return &RuleError{Rule: rule, Param: param}
+	case "regexp":
+		if val.Kind() != reflect.String {
+			return nil
+		}
+		pattern, err := compilePattern(param)
+		if err != nil {
+			return err
+		}
+		if !pattern.MatchString(val.String()) {
+			return &RuleError{Rule: rule, Param: param}
+		}
+	case "email":
+		if val.Kind() == reflect.String && !emailPattern.MatchString(val.String()) {
+			return &RuleError{Rule: rule}
+		}
+	case "uuid":
+		if val.Kind() != reflect.String {
+			return nil
+		}
+		if _, err := uuid.Parse(val.String()); err != nil {
+			return &RuleError{Rule: rule}
+		}
+	}
+	return nil
+}
+
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if pattern, ok := patterns.Load(expr); ok {
+		return pattern.(*regexp.Regexp), nil
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(expr, pattern)
+	return pattern, nil
+}
+
+// callValidate calls the Validate method of the struct oval, the errors are attached to path,
+// or below it when Validate returns Errors or a FieldError
+func (it *iteration) callValidate(oval reflect.Value, path string) {
+	var validator Validator
+	if oval.CanAddr() {
+		validator, _ = oval.Addr().Interface().(Validator)
+	}
+	if validator == nil {
+		validator, _ = oval.Interface().(Validator)
+	}
+	if validator == nil {
+		return
+	}
+
+	switch err := validator.Validate().(type) {
+	case nil:
+	case Errors:
+		for _, e := range err {
+			it.addError(joinPath(path, e.Path), e.Err)
+		}
+	case *FieldError:
+		it.addError(joinPath(path, err.Path), err.Err)
+	default:
+		it.addError(path, err)
+	}
+}
+
+// validateFields calls the Validate method of the fields of the struct oval given a value
+// from the input, and of their elements. Struct values are validated by finishStruct
+func (it *iteration) validateFields(oval reflect.Value, path string) {
+	for _, f := range it.profileFields(oval.Type()) {
+		fpath := joinPath(path, f.Name)
+		if !it.present.Has(fpath) {
+			continue
+		}
+		if fval, ok := fieldByIndex(oval, f.index, false); ok {
+			it.validateValue(fval, fpath)
+		}
+	}
+}
+
+// validateValue calls the Validate method of a value that is not a struct,
+// then of the elements of slices, arrays and maps with string keys
+func (it *iteration) validateValue(val reflect.Value, path string) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct, reflect.Invalid:
+		return
+	case reflect.Slice, reflect.Array:
+		it.callValidate(val, path)
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < val.Len(); i++ {
+			it.validateValue(val.Index(i), joinPath(path, strconv.Itoa(i)))
+		}
+	case reflect.Map:
+		it.callValidate(val, path)
+		if val.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, k := range val.MapKeys() {
+			it.validateValue(val.MapIndex(k), joinPath(path, k.String()))
+		}
+	default:
+		it.callValidate(val, path)
+	}
+}
+
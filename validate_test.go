@@ -0,0 +1,116 @@
+package teepr
+
+import (
+	"errors"
+	"testing"
+)
+
+type SignupRequest struct {
+	Id       string   `teepr:",uuid"`
+	Username string   `teepr:",min=3,max=16,regexp=^[a-z0-9_]+$"`
+	Email    string   `teepr:",email"`
+	Pin      string   `teepr:",len=6"`
+	Age      int      `teepr:",min=17"`
+	Plan     string   `teepr:",oneof=free pro,default=free"`
+	Tags     []string `teepr:",max=2"`
+}
+
+func hasRuleError(errs Errors, path, rule string) bool {
+	for _, e := range errs {
+		var ruleErr *RuleError
+		if e.Path == path && errors.As(e.Err, &ruleErr) && ruleErr.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidate(t *testing.T) {
+	t.Log("Testing valid input passes the validation rules")
+	{
+		input := map[string]interface{}{
+			"Id":       "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			"Username": "userex",
+			"Email":    "userex@example.com",
+			"Pin":      "123456",
+			"Age":      30,
+		}
+		var output SignupRequest
+		if err := Teepr(input, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		if output.Plan != "free" {
+			t.Fatalf("%s expected Plan = free, got %s", failed, output.Plan)
+		}
+		t.Logf("%s Result: %+v", success, output)
+	}
+
+	t.Log("Testing every broken rule is reported at its path")
+	{
+		input := map[string]interface{}{
+			"Id":       "not-a-uuid",
+			"Username": "User Ex",
+			"Email":    "userex",
+			"Pin":      "123",
+			"Age":      12,
+			"Plan":     "gold",
+			"Tags":     []interface{}{"a", "b", "c"},
+		}
+		var output SignupRequest
+		err := Teepr(input, &output)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != 7 {
+			t.Fatalf("%s expected 7 errors, got %v", failed, err)
+		}
+		expected := [][2]string{
+			{"Id", "uuid"}, {"Username", "regexp"}, {"Email", "email"}, {"Pin", "len"},
+			{"Age", "min"}, {"Plan", "oneof"}, {"Tags", "max"},
+		}
+		for _, e := range expected {
+			if !hasRuleError(errs, e[0], e[1]) {
+				t.Fatalf("%s expected %s error at %s, got %v", failed, e[1], e[0], err)
+			}
+		}
+		if !errors.Is(errs[0], ErrInvalid) {
+			t.Fatalf("%s expected errors wrapping ErrInvalid, got %v", failed, errs[0])
+		}
+		t.Logf("%s Result: %s", success, err.Error())
+	}
+
+	t.Log("Testing rules of nested slice elements")
+	{
+		type Basket struct {
+			Items []struct {
+				Price float64 `teepr:",min=1"`
+			}
+		}
+		input := map[string]interface{}{
+			"Items": []interface{}{
+				map[string]interface{}{"Price": 10.0},
+				map[string]interface{}{"Price": 0.5},
+			},
+		}
+		var output Basket
+		errs, _ := Teepr(input, &output).(Errors)
+		if len(errs) != 1 || !hasRuleError(errs, "Items.1.Price", "min") {
+			t.Fatalf("%s expected min error at Items.1.Price, got %v", failed, errs)
+		}
+		t.Logf("%s Result: %s", success, errs.Error())
+	}
+
+	t.Log("Testing a quoted rule value holding commas")
+	{
+		type AreaCode struct {
+			Code string `teepr:",regexp='^[0-9]{2,4}$',max=4"`
+		}
+		var output AreaCode
+		if err := Teepr(map[string]interface{}{"Code": "123"}, &output); err != nil {
+			t.Fatalf("%s expected error nil, got %s", failed, err.Error())
+		}
+		errs, _ := Teepr(map[string]interface{}{"Code": "12345"}, &output).(Errors)
+		if len(errs) != 2 || !hasRuleError(errs, "Code", "regexp") || !hasRuleError(errs, "Code", "max") {
+			t.Fatalf("%s expected regexp and max errors at Code, got %v", failed, errs)
+		}
+		t.Logf("%s Result: %s", success, errs.Error())
+	}
+}
@@ -0,0 +1,98 @@
+package teepr
+
+import (
+	"errors"
+	"testing"
+)
+
+var (
+	errNoItems  = errors.New("order without items")
+	errNegative = errors.New("negative price")
+)
+
+type ValidatedOrder struct {
+	Id    string
+	Items []ValidatedItem
+}
+
+func (o *ValidatedOrder) Validate() error {
+	if len(o.Items) == 0 {
+		return &FieldError{Path: "Items", Err: errNoItems}
+	}
+	return nil
+}
+
+type ValidatedItem struct {
+	Id    string
+	Price float64
+}
+
+func (i ValidatedItem) Validate() error {
+	if i.Price < 0 {
+		return errNegative
+	}
+	return nil
+}
+
+var errUnknownCode = errors.New("unknown code")
+
+type Code string
+
+func (c Code) Validate() error {
+	if c != "ok" {
+		return errUnknownCode
+	}
+	return nil
+}
+
+type CodesIn struct {
+	Codes []Code
+	C     Code
+}
+
+func TestValidator(t *testing.T) {
+	t.Log("Testing Validate is called on the output and its slice elements")
+	{
+		input := map[string]interface{}{
+			"Id": "ord123",
+			"Items": []interface{}{
+				map[string]interface{}{"Id": "itm1", "Price": 10.0},
+				map[string]interface{}{"Id": "itm2", "Price": -1.0},
+			},
+		}
+		var output ValidatedOrder
+		errs, _ := Teepr(input, &output).(Errors)
+		if len(errs) != 1 || !hasFieldError(errs, "Items.1", errNegative) {
+			t.Fatalf("%s expected negative price at Items.1, got %v", failed, errs)
+		}
+		t.Logf("%s Result: %s", success, errs.Error())
+	}
+
+	t.Log("Testing Validate errors with a path are attached below the value")
+	{
+		input := OrderEx{Id: "ord123"}
+		var output ValidatedOrder
+		errs, _ := Teepr(input, &output).(Errors)
+		if len(errs) != 1 || !hasFieldError(errs, "Items", errNoItems) {
+			t.Fatalf("%s expected order without items at Items, got %v", failed, errs)
+		}
+		t.Logf("%s Result: %s", success, errs.Error())
+	}
+
+	t.Log("Testing Validate is called on scalar values and slice elements")
+	{
+		var output CodesIn
+		errs, _ := Teepr(CodesIn{Codes: []Code{"ok", "bad"}, C: "bad"}, &output).(Errors)
+		if len(errs) != 2 || !hasFieldError(errs, "Codes.1", errUnknownCode) || !hasFieldError(errs, "C", errUnknownCode) {
+			t.Fatalf("%s expected unknown code at Codes.1 and C, got %v", failed, errs)
+		}
+		t.Logf("%s Result: %s", success, errs.Error())
+
+		var codes []Code
+		errs, _ = Teepr([]Code{"ok", "bad"}, &codes).(Errors)
+		if len(errs) != 1 || !hasFieldError(errs, "1", errUnknownCode) {
+			t.Fatalf("%s expected unknown code at 1, got %v", failed, errs)
+		}
+		t.Logf("%s Result: %s", success, errs.Error())
+	}
+}